@@ -8,25 +8,54 @@ import (
 
 type Config struct {
 	App struct {
-		Db     string `yaml:"db"`
-		Editor string `yaml:"editor"`
+		Db      string `yaml:"db"`
+		Editor  string `yaml:"editor"`
+		Encrypt bool   `yaml:"encrypt"` // 加密存储模式, 笔记加密后再提交到仓库
+		Journal string `yaml:"journal"` // 日记目录, 相对存储目录, 默认 journal
 	} `yaml:"app"`
 	Git struct {
-		RemoteURL string `yaml:"url"`
-		User      string `yaml:"user"`
-		Password  string `yaml:"password"`
-		Branch    string `yaml:"branch"`
+		RemoteURL     string   `yaml:"url"`
+		User          string   `yaml:"user"`
+		Password      string   `yaml:"password"` // 已废弃, 请使用 token
+		Branch        string   `yaml:"branch"`
+		Auth          string   `yaml:"auth"`           // auto/ssh/agent/token, 默认 auto 根据 url 自动选择
+		Token         string   `yaml:"token"`          // https token, 也可以通过环境变量 NOTE_GIT_TOKEN 设置
+		SSHKey        string   `yaml:"ssh_key"`        // ssh 私钥路径, 为空时依次尝试 ssh-agent 和 ~/.ssh/id_*
+		SSHPassphrase string   `yaml:"ssh_passphrase"` // 私钥密码, 也可以通过环境变量 NOTE_SSH_PASSPHRASE 设置
+		Remotes       []Remote `yaml:"remotes"`        // 镜像仓库, 同步时推送到全部仓库
+		Primary       string   `yaml:"primary"`        // 拉取的主仓库, 默认 origin 即 url
+		SyncInterval  string   `yaml:"sync_interval"`  // 提醒服务后台同步的间隔, 默认 5m, off 关闭
 	} `yaml:"github"`
+	Notify struct {
+		Backends []string `yaml:"backends"` // desktop/webhook/file/stdout, 为空时使用 stdout, file 以及可用的 desktop
+		Webhook  string   `yaml:"webhook"`  // webhook 地址, 提醒时 POST JSON
+		File     string   `yaml:"file"`     // 提醒日志文件, 默认存储目录下的 .note/remind.log
+	} `yaml:"notify"`
+}
+
+// 镜像仓库, 认证方式为空时根据 url 自动选择
+type Remote struct {
+	Name  string `yaml:"name"`
+	URL   string `yaml:"url"`
+	Auth  string `yaml:"auth"`
+	Token string `yaml:"token"` // 也可以通过环境变量 NOTE_GIT_TOKEN_<NAME> 设置, 如 NOTE_GIT_TOKEN_GITEE
 }
 
 var DefaultCfg = &Config{}
 
 var Path = "/etc/note_config.yaml"
 
-func init() {
-	//flag.StringVar(&Path, "config", "", "config file path")
-	//flag.Parse()
-	//log.Log("ConfigPath:", Path)
+// 配置文件路径的环境变量, 优先级低于命令行 --config
+const EnvPath = "NOTE_CONFIG"
+
+// 加载配置, path 为空时依次使用环境变量 NOTE_CONFIG 和默认路径
+func Load(path string) {
+	if path == "" {
+		path = os.Getenv(EnvPath)
+	}
+	if path != "" {
+		Path = path
+	}
 	loadConfig()
 }
 
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 命令树: 每个命令有自己的参数个数校验和 flag, 支持子命令以及 --help
+
+type Command struct {
+	Name     string
+	Aliases  []string
+	Args     string // 参数说明, 如 "<fileName/number/@id>"
+	Short    string // 一行说明
+	MinArgs  int
+	MaxArgs  int                    // -1 表示不限制
+	Flags    func(fs *flag.FlagSet) // 注册该命令的 flag
+	Run      func(args []string) error
+	Subs     []*Command
+	Hidden   bool         // 不在帮助中显示
+	Complete CompleteFunc // 位置参数的补全, 见 completion.go
+
+	parent *Command
+}
+
+// 参数错误, 输出错误信息和该命令的用法
+type UsageError struct {
+	Cmd *Command
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (c *Command) add(subs ...*Command) *Command {
+	for _, s := range subs {
+		s.parent = c
+		c.Subs = append(c.Subs, s)
+	}
+	return c
+}
+
+// 完整命令名, 如 note todo add
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+func (c *Command) Find(name string) *Command {
+	for _, s := range c.Subs {
+		if s.Name == name {
+			return s
+		}
+		for _, a := range s.Aliases {
+			if a == name {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Command) Execute(args []string) error {
+	if len(c.Subs) > 0 && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if sub := c.Find(args[0]); sub != nil {
+			return sub.Execute(args[1:])
+		}
+		if c.Run == nil {
+			return &UsageError{c, unknownError(c, args[0])}
+		}
+	}
+
+	fs := flag.NewFlagSet(c.Path(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if c.Flags != nil {
+		c.Flags(fs)
+	}
+	pos, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		c.PrintUsage(os.Stdout)
+		return nil
+	}
+	if err != nil {
+		return &UsageError{c, err}
+	}
+	if c.Run == nil {
+		c.PrintUsage(os.Stdout)
+		return nil
+	}
+	if len(pos) < c.MinArgs {
+		return &UsageError{c, fmt.Errorf("缺少参数, 至少需要 %d 个参数", c.MinArgs)}
+	}
+	if c.MaxArgs >= 0 && len(pos) > c.MaxArgs {
+		return &UsageError{c, fmt.Errorf("参数过多, 最多 %d 个参数", c.MaxArgs)}
+	}
+	return c.Run(pos)
+}
+
+// 允许 flag 出现在位置参数之后, 如 note add a.md --template meeting, -- 之后的全部视为位置参数
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for i, a := range args {
+		if a == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
+		}
+	}
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(pos, rest...), nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *Command) PrintUsage(w io.Writer) {
+	fmt.Fprintf(w, "用法: %s", c.Path())
+	if len(c.Subs) > 0 {
+		fmt.Fprint(w, " <command>")
+	}
+	if c.Args != "" {
+		fmt.Fprint(w, " "+c.Args)
+	}
+	fmt.Fprintln(w)
+	if c.Short != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Short)
+	}
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(w, "\n别名: %s\n", strings.Join(c.Aliases, ", "))
+	}
+	if c.Flags != nil {
+		fs := flag.NewFlagSet(c.Path(), flag.ContinueOnError)
+		c.Flags(fs)
+		fmt.Fprintln(w, "\n参数:")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+	if len(c.Subs) > 0 {
+		fmt.Fprintln(w, "\n子命令:")
+		for _, s := range c.Subs {
+			if !s.Hidden {
+				fmt.Fprintf(w, "  %-12s %s\n", s.Name, s.Short)
+			}
+		}
+	}
+}
+
+// 未知命令, 给出最相近的命令提示
+func unknownError(c *Command, name string) error {
+	best, bestDist := "", 3
+	for _, s := range c.Subs {
+		if s.Hidden {
+			continue
+		}
+		for _, n := range append([]string{s.Name}, s.Aliases...) {
+			if d := distance(name, n); d < bestDist {
+				best, bestDist = n, d
+			}
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("未知命令 %q, 你是不是想执行 %s %s ?", name, c.Path(), best)
+	}
+	return fmt.Errorf("未知命令 %q", name)
+}
+
+// 编辑距离
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,733 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"note/cfg"
+	"note/client/git"
+	"note/client/lib"
+	"note/client/mcp"
+	"note/shell"
+	"os"
+	"strings"
+)
+
+// 命令入口, args 不包含程序名
+func Execute(args []string) {
+	// 兼容 note -k keyword
+	if len(args) > 0 && args[0] == "-k" {
+		args = append([]string{"grep"}, args...)
+	}
+
+	fs := flag.NewFlagSet("note", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", "", "配置文件路径, 默认读取环境变量 "+cfg.EnvPath+" 或 "+cfg.Path)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			args = []string{"help"}
+		} else {
+			exitWithError(&UsageError{root, err})
+		}
+	} else {
+		args = fs.Args()
+	}
+
+	cfg.Load(*configPath)
+	lib.Init()
+	git.HelpStr = HelpString()
+
+	if len(args) == 0 {
+		lib.Help()
+		return
+	}
+	// note 1 / note @k3x9 / note 已存在的文件 直接编辑
+	if root.Find(args[0]) == nil && len(args) == 1 && lib.NoteExists(args[0]) {
+		lib.Edit(args[0])
+		return
+	}
+	if err := root.Execute(args); err != nil {
+		exitWithError(err)
+	}
+}
+
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "%s错误: %v%s\n", shell.BrightRed, err, shell.ResetAll)
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		fmt.Fprintf(os.Stderr, "执行 %s --help 查看用法\n", ue.Cmd.Path())
+	}
+	os.Exit(1)
+}
+
+// 生成帮助信息, 也用作初始化仓库时的 readme
+func HelpString() string {
+	var sb strings.Builder
+	sb.WriteString("使用方法:")
+	for _, c := range root.Subs {
+		if c.Hidden {
+			continue
+		}
+		name := strings.Join(append([]string{c.Name}, c.Aliases...), "/")
+		sb.WriteString("\n	note " + name)
+		if len(c.Subs) > 0 {
+			names := make([]string, 0, len(c.Subs))
+			for _, s := range c.Subs {
+				if !s.Hidden {
+					names = append(names, s.Name)
+				}
+			}
+			sb.WriteString(" " + strings.Join(names, "|"))
+		}
+		if c.Args != "" {
+			sb.WriteString(" " + c.Args)
+		}
+		sb.WriteString(" // " + c.Short)
+	}
+	sb.WriteString("\n	note <command> --help // 查看命令的详细用法")
+	sb.WriteString("\n	note --config path <command> // 指定配置文件")
+	return sb.String()
+}
+
+// 无需返回错误的命令
+func run(fn func(args []string)) func(args []string) error {
+	return func(args []string) error {
+		fn(args)
+		return nil
+	}
+}
+
+var root *Command
+
+// 在 init 中构建, 避免 help 命令引用 root 造成初始化循环
+func init() {
+	root = newRoot()
+}
+
+func newRoot() *Command {
+	return (&Command{Name: "note"}).add(
+		addCommand(),
+		&Command{
+			Name:    "templates",
+			Short:   "列出存储目录 templates 下的笔记模板",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.ListTemplates() }),
+		},
+		&Command{
+			Name:    "addDir",
+			Args:    "<dirName>",
+			Short:   "新增目录, 支持多级目录",
+			MinArgs: 1, MaxArgs: 1,
+			Run: run(func(args []string) { lib.CreateDir(args[0]) }),
+		},
+		listCommand(),
+		findCommand(),
+		&Command{
+			Name:    "ui",
+			Short:   "全屏浏览笔记: 可折叠的目录树, 预览, 模糊过滤, 编辑/新建/移动/删除/提交",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.UI() },
+		},
+		viewCommand(),
+		exportCommand(),
+		&Command{
+			Name:    "s",
+			Aliases: []string{"search"},
+			Args:    "<keyWord>",
+			Short:   "全文搜索关键字, 按相关度排序, 支持中文",
+			MinArgs: 1, MaxArgs: -1,
+			Run: run(func(args []string) { lib.Search(strings.Join(args, " ")) }),
+		},
+		&Command{
+			Name:    "tags",
+			Short:   "列出所有标签",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.ListTags() }),
+		},
+		&Command{
+			Name:    "tag",
+			Args:    "<tag>",
+			Short:   "列出带有该标签的笔记",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeTags,
+			Run:      run(func(args []string) { lib.ShowTag(args[0]) }),
+		},
+		&Command{
+			Name:    "links",
+			Args:    "<fileName/number/@id>",
+			Short:   "查看笔记中的 [[链接]]",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeNotes,
+			Run:      run(func(args []string) { lib.ShowLinks(args[0]) }),
+		},
+		&Command{
+			Name:    "backlinks",
+			Args:    "<fileName/number/@id>",
+			Short:   "查看哪些笔记链接到了该笔记",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeNotes,
+			Run:      run(func(args []string) { lib.ShowBacklinks(args[0]) }),
+		},
+		&Command{
+			Name:    "check",
+			Short:   "检查失效的 [[链接]]",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.CheckLinks() }),
+		},
+		&Command{
+			Name:    "move",
+			Aliases: []string{"mv"},
+			Args:    "<srcPath/number/@id> <targetPath>",
+			Short:   "移动文件, 也支持重命名 note move java/a.go golang/b.go",
+			MinArgs: 2, MaxArgs: 2,
+			Complete: completeNotes,
+			Run:      run(func(args []string) { lib.MoveFile(args[0], args[1]) }),
+		},
+		rmCommand(),
+		trashCommand(),
+		&Command{
+			Name:    "encrypt",
+			Args:    "<path>",
+			Short:   "加密文件/目录下的笔记, 密码可通过环境变量 " + lib.EnvPassphrase + " 设置",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeNotes,
+			Run:      run(func(args []string) { lib.EncryptNotes(args[0]) }),
+		},
+		&Command{
+			Name:    "decrypt",
+			Args:    "<path>",
+			Short:   "解密文件/目录下的笔记",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeNotes,
+			Run:      run(func(args []string) { lib.DecryptNotes(args[0]) }),
+		},
+		&Command{
+			Name:    "init",
+			Short:   "初始化仓库",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.InitGit() }),
+		},
+		&Command{
+			Name:    "commit",
+			Aliases: []string{"ci"},
+			Args:    "[message]",
+			Short:   "提交所有修改",
+			MaxArgs: -1,
+			Run:     run(func(args []string) { lib.CommitGit(strings.Join(args, " ")) }),
+		},
+		&Command{
+			Name:    "push",
+			Short:   "与主仓库同步并推送到全部远程仓库, 冲突时三方合并",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.SyncGit() }),
+		},
+		&Command{
+			Name:    "pull",
+			Short:   "拉取主仓库",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.PullGit() }),
+		},
+		remoteCommand(),
+		syncCommand(),
+		&Command{
+			Name:    "log",
+			Short:   "查看仓库提交日志",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.ShowLog() }),
+		},
+		&Command{
+			Name:    "history",
+			Args:    "<fileName/number/@id>",
+			Short:   "查看修改过该笔记的提交, 移动过的笔记会跟随到旧路径",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeNotes,
+			Run:      func(args []string) error { return lib.ShowHistory(args[0]) },
+		},
+		&Command{
+			Name:    "show",
+			Args:    "<note>@<rev>",
+			Short:   "查看笔记的历史版本, rev 为 note history 中的序号或提交哈希, 举例 note show a.md@2",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeNotes,
+			Run:      func(args []string) error { return lib.ShowVersion(args[0]) },
+		},
+		&Command{
+			Name:    "diff",
+			Args:    "<note> [rev1] [rev2]",
+			Short:   "按词比较笔记的两个版本, 默认比较上一个版本和当前内容",
+			MinArgs: 1, MaxArgs: 3,
+			Complete: completeNotes,
+			Run:      func(args []string) error { return lib.DiffNote(args[0], args[1:]) },
+		},
+		&Command{
+			Name:    "restore",
+			Args:    "<note> <rev>",
+			Short:   "将笔记恢复到历史版本并提交, 已删除的笔记也可以恢复",
+			MinArgs: 2, MaxArgs: 2,
+			Complete: completeNotes,
+			Run:      func(args []string) error { return lib.RestoreNote(args[0], args[1]) },
+		},
+		&Command{
+			Name:    "lz",
+			Args:    "<path>",
+			Short:   "查看 path 目录下大文件",
+			MinArgs: 1, MaxArgs: 1,
+			Run: run(func(args []string) { shell.Find(args[0]) }),
+		},
+		grepCommand(),
+		completionCommand(),
+		completeCommand(),
+		daemonCommand(),
+		&Command{
+			Name:    "start",
+			Short:   "后台启动提醒服务, 同 note daemon start",
+			MaxArgs: 0,
+			Hidden:  true,
+			Run:     func(args []string) error { return lib.StartDaemon() },
+		},
+		serverCommand(),
+		serveCommand(),
+		remindCommand(),
+		todoCommand(),
+		&Command{
+			Name:    "today",
+			Short:   "打开今天的日记",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.OpenJournal("today") },
+		},
+		&Command{
+			Name:    "yesterday",
+			Short:   "打开昨天的日记",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.OpenJournal("yesterday") },
+		},
+		journalCommand(),
+		&Command{
+			Name:    "mcp",
+			Short:   "以 MCP(stdio) 服务方式提供笔记的列表/查看/搜索/编辑/移动/删除工具",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { mcp.Exec() }),
+		},
+		&Command{
+			Name:     "help",
+			Aliases:  []string{"h"},
+			Args:     "[command]",
+			Short:    "查看帮助",
+			MaxArgs:  -1,
+			Complete: completeCommands,
+			Run:      helpCommand,
+		},
+	)
+}
+
+func addCommand() *Command {
+	var tmpl string
+	return &Command{
+		Name:    "add",
+		Args:    "<fileName/number/@id>",
+		Short:   "新增/编辑文件, 举例 note add ReadMe 或者 note 1 或者 note @k3x9 或者 note add m.md --template meeting",
+		MinArgs: 1, MaxArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&tmpl, "template", "", "用 templates 下的模板新建笔记")
+		},
+		Complete: completeNotes,
+		Run: func(args []string) error {
+			if tmpl == "" {
+				lib.Edit(args[0])
+				return nil
+			}
+			return lib.EditWithTemplate(args[0], tmpl)
+		},
+	}
+}
+
+func rmCommand() *Command {
+	var yes bool
+	return &Command{
+		Name:    "rm",
+		Args:    "<fileName/number/@id>",
+		Short:   "删除目录/文件, 移到回收站, 删除目录前需要确认",
+		MinArgs: 1, MaxArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&yes, "y", false, "删除目录时不再确认")
+		},
+		Complete: completeNotes,
+		Run:      run(func(args []string) { lib.RemoveFile(args[0], yes) }),
+	}
+}
+
+func trashCommand() *Command {
+	var olderThan string
+	var yes bool
+	return (&Command{
+		Name:    "trash",
+		Short:   "管理回收站, note rm 删除的文件/目录保存在存储目录的 .trash 下",
+		MaxArgs: 0,
+		Run:     func(args []string) error { return lib.ShowTrash() },
+	}).add(
+		&Command{
+			Name:    "ls",
+			Short:   "列出回收站中的文件/目录及删除时间",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.ShowTrash() },
+		},
+		&Command{
+			Name:    "restore",
+			Args:    "<id>",
+			Short:   "恢复到原路径并提交",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeTrash,
+			Run:      func(args []string) error { return lib.RestoreTrash(args[0]) },
+		},
+		&Command{
+			Name:    "empty",
+			Short:   "彻底删除回收站中的项, 举例 note trash empty --older-than 30d",
+			MaxArgs: 0,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&olderThan, "older-than", "", "只删除超过该时长的项, 如 30d / 12h, 不指定时清空回收站")
+				fs.BoolVar(&yes, "y", false, "清空回收站时不再确认")
+			},
+			Run: func(args []string) error { return lib.EmptyTrash(olderThan, yes) },
+		},
+	)
+}
+
+func viewCommand() *Command {
+	var opt lib.ViewOptions
+	return &Command{
+		Name:    "view",
+		Aliases: []string{"v"},
+		Args:    "<fileName/number/@id>",
+		Short:   "查看文件内容, Markdown 渲染后显示, 超过一屏时分页",
+		MinArgs: 1, MaxArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&opt.Raw, "raw", false, "原样输出, 不渲染")
+			fs.StringVar(&opt.Style, "style", "", "代码着色样式, 如 monokai, github, dracula")
+		},
+		Complete: completeNotes,
+		Run:      func(args []string) error { return lib.ViewNote(args[0], opt) },
+	}
+}
+
+func exportCommand() *Command {
+	var opt lib.ExportOptions
+	return &Command{
+		Name:    "export",
+		Args:    "[path|dir]",
+		Short:   "导出笔记为 HTML 站点, Markdown 或纯文本, 不指定路径时导出全部",
+		MaxArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&opt.Format, "format", "html", "导出格式: html, md, txt")
+			fs.StringVar(&opt.Out, "o", "export", "输出目录")
+			fs.BoolVar(&opt.Single, "single", false, "合并为一个自包含的 HTML 文件")
+			fs.StringVar(&opt.Style, "style", "", "代码着色样式, 默认 github")
+		},
+		Complete: completeNotes,
+		Run: func(args []string) error {
+			target := ""
+			if len(args) > 0 {
+				target = args[0]
+			}
+			return lib.ExportNotes(target, opt)
+		},
+	}
+}
+
+func findCommand() *Command {
+	var opt lib.FindOptions
+	return &Command{
+		Name:    "f",
+		Aliases: []string{"find"},
+		Args:    "[query]",
+		Short:   "按路径和标题模糊查找笔记, 终端中交互选择, 否则按相关度输出",
+		MaxArgs: -1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&opt.Open, "open", false, "用编辑器打开选中的笔记")
+			fs.BoolVar(&opt.View, "view", false, "查看选中的笔记")
+		},
+		Run: func(args []string) error { return lib.FindNote(strings.Join(args, " "), opt) },
+	}
+}
+
+func listCommand() *Command {
+	var tag string
+	return &Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Short:   "列出存储目录结构及笔记ID(@k3x9), 可按标签过滤",
+		MaxArgs: 0,
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&tag, "tag", "", "只列出带有该标签的笔记")
+		},
+		Run: run(func(args []string) { lib.List(tag) }),
+	}
+}
+
+func grepCommand() *Command {
+	opt := shell.SearchOptions{}
+	return &Command{
+		Name:    "grep",
+		Short:   "在任意目录中并发搜索关键字, 举例 note grep -k 'a|b' -d ./src",
+		MaxArgs: 0,
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&opt.Dir, "d", ".", "Search directory")
+			fs.StringVar(&opt.Keyword, "k", "", "Keywords (| for OR, & for AND with order)")
+			fs.IntVar(&opt.Workers, "w", 10, "Worker goroutines")
+			fs.StringVar(&opt.FileMatch, "f", ".*", "Filename pattern")
+			fs.IntVar(&opt.ContextLen, "l", 40, "内容长度")
+		},
+		Run: func(args []string) error {
+			if opt.Keyword == "" {
+				return errors.New("必须指定关键字 (-k)")
+			}
+			shell.Search(opt)
+			return nil
+		},
+	}
+}
+
+func helpCommand(args []string) error {
+	if len(args) == 0 {
+		lib.Help()
+		return nil
+	}
+	c := root
+	for _, name := range args {
+		sub := c.Find(name)
+		if sub == nil {
+			return &UsageError{c, unknownError(c, name)}
+		}
+		c = sub
+	}
+	c.PrintUsage(os.Stdout)
+	return nil
+}
+
+func remindCommand() *Command {
+	ls := func(args []string) { lib.ShowReminders() }
+	return (&Command{
+		Name:    "remind",
+		Short:   "查看 todolist 中的提醒, 支持 15:00 / Mon 09:30 / 2026-10-20 15:00 / 30min / every day 08:00 / every 30min",
+		MaxArgs: 0,
+		Run:     run(ls),
+	}).add(
+		&Command{
+			Name:    "ls",
+			Short:   "查看提醒及下一次提醒时间",
+			MaxArgs: 0,
+			Run:     run(ls),
+		},
+		&Command{
+			Name:    "snooze",
+			Args:    "n [10min]",
+			Short:   "第 n 条提醒推迟一段时间后再提醒, 默认 10 分钟",
+			MinArgs: 1, MaxArgs: 2,
+			Run: func(args []string) error {
+				after := ""
+				if len(args) > 1 {
+					after = args[1]
+				}
+				return lib.SnoozeReminder(args[0], after)
+			},
+		},
+	)
+}
+
+func daemonCommand() *Command {
+	var install bool
+	return (&Command{
+		Name:  "daemon",
+		Short: "管理后台提醒服务, pid 和日志保存在存储目录的 .note 下",
+	}).add(
+		&Command{
+			Name:    "start",
+			Short:   "后台启动提醒服务",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.StartDaemon() },
+		},
+		&Command{
+			Name:    "stop",
+			Short:   "停止提醒服务",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.StopDaemon() },
+		},
+		&Command{
+			Name:    "restart",
+			Short:   "重启提醒服务",
+			MaxArgs: 0,
+			Run:     func(args []string) error { return lib.RestartDaemon() },
+		},
+		&Command{
+			Name:    "status",
+			Short:   "查看提醒服务是否在运行",
+			MaxArgs: 0,
+			Run:     run(func(args []string) { lib.DaemonStatus() }),
+		},
+		&Command{
+			Name:    "unit",
+			Short:   "生成 systemd 用户服务",
+			MaxArgs: 0,
+			Flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&install, "install", false, "写入 ~/.config/systemd/user/note.service")
+			},
+			Run: func(args []string) error { return lib.SystemdUnit(install) },
+		},
+	)
+}
+
+func serveCommand() *Command {
+	opt := lib.WebOptions{Token: os.Getenv(lib.EnvServeToken)}
+	return &Command{
+		Name:    "serve",
+		Short:   "启动只读的网页浏览, 支持目录树, 全文搜索和历史版本",
+		MaxArgs: 0,
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&opt.Addr, "addr", "127.0.0.1:8080", "监听地址")
+			fs.StringVar(&opt.Token, "token", opt.Token, "访问令牌, 默认读取环境变量 "+lib.EnvServeToken+", 为空时不校验")
+			fs.StringVar(&opt.Style, "style", "", "代码着色样式, 默认 github")
+		},
+		Run: func(args []string) error { return lib.ServeWeb(opt) },
+	}
+}
+
+func serverCommand() *Command {
+	var logFile bool
+	return &Command{
+		Name:    "server",
+		Short:   "前台运行提醒服务",
+		MaxArgs: 0,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&logFile, "log", false, "输出写入存储目录下的 .note/daemon.log")
+		},
+		Run: func(args []string) error { return lib.Serve(logFile) },
+	}
+}
+
+func todoCommand() *Command {
+	var at, in, every string
+	var done bool
+	ls := func(args []string) { lib.ListTodos(done) }
+	lsFlags := func(fs *flag.FlagSet) {
+		fs.BoolVar(&done, "done", false, "列出已完成归档的待办")
+	}
+	return (&Command{
+		Name:    "todo",
+		Short:   "管理 todolist 中的待办, 带提醒时间的待办由提醒服务提醒",
+		MaxArgs: 0,
+		Flags:   lsFlags,
+		Run:     run(ls),
+	}).add(
+		&Command{
+			Name:    "add",
+			Args:    "\"内容\"",
+			Short:   "添加待办, 举例 note todo add \"开会\" --at 15:00",
+			MinArgs: 1, MaxArgs: -1,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&at, "at", "", "提醒时间, 如 15:00 / Mon 09:30 / 2026-10-20 15:00")
+				fs.StringVar(&in, "in", "", "多久之后提醒, 如 30min / 2h / 1d")
+				fs.StringVar(&every, "every", "", "重复提醒, 如 \"day 08:00\" / \"Mon,Wed 09:30\" / \"workday 09:00\" / 30min")
+			},
+			Run: func(args []string) error { return lib.AddTodo(strings.Join(args, " "), at, in, every) },
+		},
+		&Command{
+			Name:    "ls",
+			Short:   "列出未完成的待办及下一次提醒时间",
+			MaxArgs: 0,
+			Flags:   lsFlags,
+			Run:     run(ls),
+		},
+		&Command{
+			Name:    "done",
+			Args:    "n",
+			Short:   "完成第 n 条待办, 归档到 todolist.done",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeTodos,
+			Run:      func(args []string) error { return lib.DoneTodo(args[0]) },
+		},
+		&Command{
+			Name:    "rm",
+			Args:    "n",
+			Short:   "删除第 n 条待办",
+			MinArgs: 1, MaxArgs: 1,
+			Complete: completeTodos,
+			Run:      func(args []string) error { return lib.RemoveTodo(args[0]) },
+		},
+	)
+}
+
+func journalCommand() *Command {
+	var week bool
+	return (&Command{
+		Name:    "journal",
+		Args:    "[date]",
+		Short:   "打开某一天的日记, 举例 note journal 2026-10-17 / 10-17 / 2d(2 天前)",
+		MaxArgs: 1,
+		Run: func(args []string) error {
+			date := "today"
+			if len(args) > 0 {
+				date = args[0]
+			}
+			return lib.OpenJournal(date)
+		},
+	}).add(
+		&Command{
+			Name:    "ls",
+			Short:   "列出所有日记, --week 输出本周的日记",
+			MaxArgs: 0,
+			Flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&week, "week", false, "拼接输出本周的日记")
+			},
+			Run: run(func(args []string) { lib.ListJournal(week) }),
+		},
+	)
+}
+
+func remoteCommand() *Command {
+	ls := func(args []string) { lib.ShowRemotes() }
+	return (&Command{
+		Name:    "remote",
+		Short:   "管理远程仓库, 同步时从主仓库拉取, 推送到全部远程仓库",
+		MaxArgs: 0,
+		Run:     run(ls),
+	}).add(
+		&Command{
+			Name:    "ls",
+			Short:   "列出远程仓库, * 为拉取的主仓库",
+			MaxArgs: 0,
+			Run:     run(ls),
+		},
+		&Command{
+			Name:    "add",
+			Args:    "<name> <url>",
+			Short:   "添加镜像仓库, 举例 note remote add gitee git@gitee.com:user/notes.git",
+			MinArgs: 2, MaxArgs: 2,
+			Run: func(args []string) error { return lib.AddRemote(args[0], args[1]) },
+		},
+		&Command{
+			Name:    "rm",
+			Args:    "<name>",
+			Short:   "删除镜像仓库",
+			MinArgs: 1, MaxArgs: 1,
+			Run: func(args []string) error { return lib.RemoveRemote(args[0]) },
+		},
+	)
+}
+
+func syncCommand() *Command {
+	var fetch bool
+	return (&Command{
+		Name:  "sync",
+		Short: "查看同步状态, 提醒服务运行时会在后台自动同步",
+	}).add(
+		&Command{
+			Name:    "status",
+			Short:   "本地与主仓库相比领先/落后的提交数及后台同步状态",
+			MaxArgs: 0,
+			Flags: func(fs *flag.FlagSet) {
+				fs.BoolVar(&fetch, "fetch", false, "先抓取主仓库")
+			},
+			Run: func(args []string) error { return lib.SyncStatus(fetch) },
+		},
+	)
+}
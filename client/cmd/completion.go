@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"note/client/lib"
+	"note/client/meta"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 补全候选项, 输出格式为 "值\t说明", 由 note __complete 提供给补全脚本
+
+type Candidate struct {
+	Value       string
+	Description string
+}
+
+// 根据已输入的参数和当前正在输入的词返回候选项
+type CompleteFunc func(args []string, cur string) []Candidate
+
+func completionCommand() *Command {
+	return &Command{
+		Name:    "completion",
+		Args:    "bash|zsh|fish",
+		Short:   "生成 shell 补全脚本, 举例 source <(note completion bash)",
+		MinArgs: 1, MaxArgs: 1,
+		Complete: func(args []string, cur string) []Candidate {
+			if len(args) > 0 {
+				return nil
+			}
+			return filter([]Candidate{{"bash", ""}, {"zsh", ""}, {"fish", ""}}, cur)
+		},
+		Run: func(args []string) error {
+			script, ok := completionScripts[args[0]]
+			if !ok {
+				return &UsageError{root.Find("completion"), fmt.Errorf("不支持的 shell: %s", args[0])}
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+}
+
+// 补全脚本调用的隐藏命令: note __complete -- <已输入的词...> <正在输入的词>
+func completeCommand() *Command {
+	return &Command{
+		Name:    "__complete",
+		Hidden:  true,
+		MaxArgs: -1,
+		Run: func(args []string) error {
+			writeCandidates(os.Stdout, complete(args))
+			return nil
+		},
+	}
+}
+
+func writeCandidates(w io.Writer, cs []Candidate) {
+	for _, c := range cs {
+		if c.Description == "" {
+			fmt.Fprintln(w, c.Value)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", c.Value, c.Description)
+		}
+	}
+}
+
+func complete(words []string) []Candidate {
+	cur := ""
+	if len(words) > 0 {
+		cur = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	// 跳过全局参数 --config path
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		if words[0] == "--config" || words[0] == "-config" {
+			words = words[1:]
+		}
+		if len(words) > 0 {
+			words = words[1:]
+		}
+	}
+
+	c := root
+	for len(words) > 0 && len(c.Subs) > 0 {
+		sub := c.Find(words[0])
+		if sub == nil {
+			break
+		}
+		c = sub
+		words = words[1:]
+	}
+
+	if n := len(words); n > 0 && strings.HasPrefix(words[n-1], "-") {
+		if fn := flagValues[strings.TrimLeft(words[n-1], "-")]; fn != nil {
+			return fn(nil, cur)
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return completeFlags(c, cur)
+	}
+	if len(c.Subs) > 0 && len(words) == 0 {
+		cs := completeSubs(c, cur)
+		if c == root {
+			// note 1 / note @id 可以直接编辑笔记
+			cs = append(cs, completeIndexes(cur)...)
+		}
+		return cs
+	}
+	if c.Complete == nil {
+		return nil
+	}
+	// 去掉已输入的 flag, 只保留位置参数
+	var args []string
+	for _, w := range words {
+		if !strings.HasPrefix(w, "-") {
+			args = append(args, w)
+		}
+	}
+	if c.MaxArgs >= 0 && len(args) >= c.MaxArgs {
+		return nil
+	}
+	return c.Complete(args, cur)
+}
+
+// 需要补全取值的 flag
+var flagValues = map[string]CompleteFunc{
+	"tag":      completeTags,
+	"template": completeTemplates,
+}
+
+func completeSubs(c *Command, cur string) []Candidate {
+	var cs []Candidate
+	for _, s := range c.Subs {
+		if s.Hidden {
+			continue
+		}
+		for _, name := range append([]string{s.Name}, s.Aliases...) {
+			if strings.HasPrefix(name, cur) {
+				cs = append(cs, Candidate{name, s.Short})
+			}
+		}
+	}
+	return cs
+}
+
+func completeFlags(c *Command, cur string) []Candidate {
+	if c.Flags == nil {
+		return filter([]Candidate{{"--help", "查看用法"}}, cur)
+	}
+	var cs []Candidate
+	fs := flag.NewFlagSet(c.Path(), flag.ContinueOnError)
+	c.Flags(fs)
+	fs.VisitAll(func(f *flag.Flag) {
+		name := "--" + f.Name
+		if len(f.Name) == 1 {
+			name = "-" + f.Name
+		}
+		cs = append(cs, Candidate{name, f.Usage})
+	})
+	cs = append(cs, Candidate{"--help", "查看用法"})
+	return filter(cs, cur)
+}
+
+// 补全笔记: 存储目录下的相对路径, 目录树下标以及 @ID
+func completeNotes(args []string, cur string) []Candidate {
+	if strings.HasPrefix(cur, "@") {
+		return completeIDs(cur)
+	}
+	if cur == "" || isDigits(strings.ReplaceAll(cur, ".", "")) {
+		return append(completeIndexes(cur), completePaths(cur)...)
+	}
+	return completePaths(cur)
+}
+
+func completePaths(cur string) []Candidate {
+	dir := ""
+	if i := strings.LastIndex(cur, "/"); i >= 0 {
+		dir = cur[:i+1]
+	}
+	entries, err := os.ReadDir(filepath.Join(lib.StorePath, dir))
+	if err != nil {
+		return nil
+	}
+	var cs []Candidate
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		value := dir + e.Name()
+		if e.IsDir() {
+			value += "/"
+		}
+		if strings.HasPrefix(value, cur) {
+			cs = append(cs, Candidate{Value: value})
+		}
+	}
+	return cs
+}
+
+// 同一层级的下标, 如输入 3. 时补全 3.1 3.2 ...
+func completeIndexes(cur string) []Candidate {
+	if cur != "" && !isDigits(strings.ReplaceAll(cur, ".", "")) {
+		return nil
+	}
+	level := strings.Count(cur, ".")
+	var cs []Candidate
+	for key, path := range shell.GetKeyMap(lib.StorePath) {
+		if strings.HasPrefix(key, cur) && strings.Count(key, ".") == level {
+			desc := filepath.Base(path)
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				desc += "/"
+			}
+			cs = append(cs, Candidate{key, desc})
+		}
+	}
+	sortCandidates(cs)
+	return cs
+}
+
+func completeIDs(cur string) []Candidate {
+	ids, err := meta.LoadIDs(lib.StorePath)
+	if err != nil {
+		return nil
+	}
+	var cs []Candidate
+	filepath.Walk(lib.StorePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != lib.StorePath {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel := lib.RelPath(path)
+		if id := ids.ID(rel); id != "" && strings.HasPrefix("@"+id, cur) {
+			cs = append(cs, Candidate{"@" + id, rel})
+		}
+		return nil
+	})
+	sortCandidates(cs)
+	return cs
+}
+
+func completeTags(args []string, cur string) []Candidate {
+	var cs []Candidate
+	for _, t := range lib.TagNames() {
+		cs = append(cs, Candidate{Value: t})
+	}
+	return filter(cs, cur)
+}
+
+func completeTodos(args []string, cur string) []Candidate {
+	var cs []Candidate
+	for i, t := range lib.TodoNames() {
+		cs = append(cs, Candidate{strconv.Itoa(i + 1), t})
+	}
+	return filter(cs, cur)
+}
+
+func completeTrash(args []string, cur string) []Candidate {
+	var cs []Candidate
+	for _, item := range lib.TrashItems() {
+		cs = append(cs, Candidate{item.ID, item.Path})
+	}
+	return filter(cs, cur)
+}
+
+func completeTemplates(args []string, cur string) []Candidate {
+	var cs []Candidate
+	for _, t := range lib.TemplateNames() {
+		cs = append(cs, Candidate{Value: t})
+	}
+	return filter(cs, cur)
+}
+
+func completeCommands(args []string, cur string) []Candidate {
+	c := root
+	for _, a := range args {
+		if c = c.Find(a); c == nil {
+			return nil
+		}
+	}
+	return completeSubs(c, cur)
+}
+
+func filter(cs []Candidate, cur string) []Candidate {
+	var out []Candidate
+	for _, c := range cs {
+		if strings.HasPrefix(c.Value, cur) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// 下标按数字顺序排列, 3.10 排在 3.9 后面
+func sortCandidates(cs []Candidate) {
+	sort.Slice(cs, func(i, j int) bool {
+		a, b := cs[i].Value, cs[j].Value
+		if len(a) != len(b) && isDigits(strings.ReplaceAll(a, ".", "")) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var completionScripts = map[string]string{
+	"bash": `# note bash completion, 使用: source <(note completion bash)
+_note_complete() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    local candidates
+    candidates=$(note __complete -- "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null | cut -f1)
+    COMPREPLY=($(compgen -W "$candidates" -- "$cur"))
+    if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _note_complete note
+`,
+	"zsh": `#compdef note
+# note zsh completion, 使用: source <(note completion zsh)
+_note() {
+    local -a candidates
+    local line value desc
+    while IFS= read -r line; do
+        value="${line%%$'\t'*}"
+        desc="${line#*$'\t'}"
+        [[ "$desc" == "$line" ]] && desc=""
+        value="${value//:/\\:}"
+        if [[ -n "$desc" ]]; then
+            candidates+=("$value:$desc")
+        else
+            candidates+=("$value")
+        fi
+    done < <(note __complete -- "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)
+    _describe -V 'note' candidates -S ''
+}
+compdef _note note
+`,
+	"fish": `# note fish completion, 使用: note completion fish | source
+function __note_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    note __complete -- $words (commandline -ct) 2>/dev/null
+end
+complete -c note -f -a '(__note_complete)'
+`,
+}
@@ -0,0 +1,131 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"golang.org/x/crypto/scrypt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 笔记加密: AES-256-GCM, 密钥由密码经 scrypt 派生, 每个文件使用独立的随机盐和 nonce.
+// 加密后的文件为文本格式, 第一行是 Header, 之后是 base64(盐 + nonce + 密文), 方便 git 存储
+
+const Header = "NOTE-ENCRYPTED v1"
+
+const (
+	saltSize  = 16
+	keySize   = 32
+	lineWidth = 76
+
+	// scrypt 参数
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var ErrPassphrase = errors.New("密码错误或文件已损坏")
+
+// 同一进程内缓存派生出的密钥, 避免每个文件都重新计算 scrypt
+var (
+	keyCache   = make(map[string][]byte)
+	keyCacheMu sync.Mutex
+)
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Header+"\n"))
+}
+
+func IsEncryptedFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(Header)+1)
+	n, _ := f.Read(head)
+	return IsEncrypted(head[:n])
+}
+
+func Encrypt(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	raw := append(append(salt, nonce...), gcm.Seal(nil, nonce, plain, []byte(Header))...)
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	var buf bytes.Buffer
+	buf.WriteString(Header + "\n")
+	for len(encoded) > lineWidth {
+		buf.WriteString(encoded[:lineWidth] + "\n")
+		encoded = encoded[lineWidth:]
+	}
+	buf.WriteString(encoded + "\n")
+	return buf.Bytes(), nil
+}
+
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("文件未加密")
+	}
+	body := strings.Join(strings.Fields(string(data[len(Header)+1:])), "")
+	raw, err := base64.StdEncoding.DecodeString(body)
+	if err != nil || len(raw) < saltSize {
+		return nil, ErrPassphrase
+	}
+	gcm, err := newGCM(passphrase, raw[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	raw = raw[saltSize:]
+	if len(raw) < gcm.NonceSize() {
+		return nil, ErrPassphrase
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], []byte(Header))
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	return plain, nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	cacheKey := passphrase + "\x00" + string(salt)
+	keyCacheMu.Lock()
+	defer keyCacheMu.Unlock()
+	if key, ok := keyCache[cacheKey]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+	keyCache[cacheKey] = key
+	return key, nil
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// 用 flock 锁住 pid 文件保证只有一个提醒服务在运行,
+// 进程退出(包括被 kill -9)后锁自动释放, 不会留下失效的 pid 文件
+
+var ErrRunning = errors.New("提醒服务已在运行")
+
+type Lock struct {
+	f *os.File
+}
+
+// 加锁并写入当前进程 pid, 已被其它进程锁住时返回 ErrRunning
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			if pid, ok := Running(path); ok {
+				return nil, fmt.Errorf("%w, pid %d", ErrRunning, pid)
+			}
+			return nil, ErrRunning
+		}
+		return nil, err
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+func (l *Lock) Release() error {
+	defer l.f.Close()
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
+
+// pid 文件被锁住时返回持有锁的进程 pid
+func Running(path string) (int, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err == nil {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, true
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, true
+}
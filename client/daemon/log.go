@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 后台运行时的日志文件, 每行前加上时间, 超过 MaxSize 后依次重命名为
+// .1 .2 ... 只保留 Backups 个旧文件
+type RotateWriter struct {
+	Path    string
+	MaxSize int64
+	Backups int
+
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	midLine bool
+}
+
+func NewRotateWriter(path string) *RotateWriter {
+	return &RotateWriter{Path: path, MaxSize: 1 << 20, Backups: 3}
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if !w.midLine && w.size >= w.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	var buf []byte
+	for _, b := range p {
+		if !w.midLine {
+			buf = append(buf, time.Now().Format("2006-01-02 15:04:05 ")...)
+		}
+		buf = append(buf, b)
+		w.midLine = b != '\n'
+	}
+	n, err := w.f.Write(buf)
+	w.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+func (w *RotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+func (w *RotateWriter) rotate() error {
+	w.f.Close()
+	w.f = nil
+	for i := w.Backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.Path, i), fmt.Sprintf("%s.%d", w.Path, i+1))
+	}
+	if w.Backups > 0 {
+		if err := os.Rename(w.Path, w.Path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(w.Path); err != nil {
+		return err
+	}
+	return w.open()
+}
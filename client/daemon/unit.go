@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const UnitName = "note.service"
+
+// systemd 用户服务, 前台运行 note server, 输出由 journald 收集
+func Unit(exe, config string) string {
+	return fmt.Sprintf(`[Unit]
+Description=note 提醒服务
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s --config %s server
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=default.target
+`, exe, config)
+}
+
+// ~/.config/systemd/user/note.service
+func UnitPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "systemd", "user", UnitName), nil
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"note/cfg"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 认证方式, 配置在 github.auth 中, 默认 auto 根据远程地址自动选择:
+// git@host:repo 或 ssh:// 使用 SSH(配置了私钥用私钥, 否则优先 ssh-agent), https:// 使用 token
+const (
+	AuthAuto  = "auto"
+	AuthSSH   = "ssh"
+	AuthAgent = "agent"
+	AuthToken = "token"
+)
+
+// 环境变量优先于配置文件, 避免在配置文件中保存明文密钥
+const (
+	EnvToken         = "NOTE_GIT_TOKEN"
+	EnvSSHPassphrase = "NOTE_SSH_PASSPHRASE"
+)
+
+// origin 的认证, 只在需要访问远程仓库时加载一次
+func (c *GitHubClient) defaultAuth() (transport.AuthMethod, error) {
+	if c.authLoaded {
+		return c.auth, nil
+	}
+	auth, err := remoteAuth(c.RemoteURL, c.SSHKeyPath, cfg.DefaultCfg.Git.Auth, "")
+	if err != nil {
+		return nil, err
+	}
+	c.auth, c.authLoaded = auth, true
+	return auth, nil
+}
+
+// 按指定的认证方式和 token 创建认证, 为空时使用 github 中的全局配置
+func remoteAuth(remoteURL, sshKeyPath, method, token string) (transport.AuthMethod, error) {
+	c := cfg.DefaultCfg.Git
+	if sshKeyPath == "" {
+		sshKeyPath = c.SSHKey
+	}
+
+	method = strings.ToLower(method)
+	var ep *transport.Endpoint
+	if remoteURL != "" {
+		var err error
+		if ep, err = transport.NewEndpoint(remoteURL); err != nil {
+			return nil, fmt.Errorf("远程仓库地址解析失败: %v", err)
+		}
+	}
+	if method == "" || method == AuthAuto {
+		method = detectAuth(ep, sshKeyPath)
+	}
+
+	switch method {
+	case "":
+		// 本地仓库不需要认证
+		return nil, nil
+	case AuthSSH:
+		if sshKeyPath == "" {
+			sshKeyPath = defaultSSHKey()
+		}
+		if sshKeyPath == "" {
+			return nil, errors.New("未找到 SSH 私钥, 请在配置中设置 github.ssh_key")
+		}
+		passphrase := os.Getenv(EnvSSHPassphrase)
+		if passphrase == "" {
+			passphrase = c.SSHPassphrase
+		}
+		auth, err := ssh.NewPublicKeysFromFile(sshUser(ep), expandHome(sshKeyPath), passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("加载 SSH 私钥失败: %v", err)
+		}
+		return auth, nil
+	case AuthAgent:
+		auth, err := ssh.NewSSHAgentAuth(sshUser(ep))
+		if err != nil {
+			return nil, fmt.Errorf("连接 ssh-agent 失败: %v", err)
+		}
+		return auth, nil
+	case AuthToken, "password":
+		if token == "" {
+			token = os.Getenv(EnvToken)
+		}
+		if token == "" {
+			token = c.Token
+		}
+		if token == "" {
+			// 兼容旧配置中的 password
+			token = c.Password
+		}
+		if token == "" {
+			// 没有 token 时远程只会返回 401, 提前说明如何设置
+			return nil, fmt.Errorf("未设置 https token, 请设置环境变量 %s 或配置 github.token(镜像仓库为 %s<NAME> 或 remotes 中的 token)", EnvToken, EnvRemoteToken)
+		}
+		user := c.User
+		if user == "" {
+			// GitHub/Gitee 使用 token 时用户名可以任意填写
+			user = "note"
+		}
+		return &http.BasicAuth{Username: user, Password: token}, nil
+	}
+	return nil, fmt.Errorf("不支持的认证方式: %s", method)
+}
+
+// 根据远程地址的协议选择认证方式
+func detectAuth(ep *transport.Endpoint, sshKeyPath string) string {
+	if ep == nil {
+		return ""
+	}
+	switch ep.Protocol {
+	case "ssh":
+		if sshKeyPath == "" && os.Getenv("SSH_AUTH_SOCK") != "" {
+			return AuthAgent
+		}
+		return AuthSSH
+	case "http", "https":
+		return AuthToken
+	}
+	return ""
+}
+
+func sshUser(ep *transport.Endpoint) string {
+	if ep != nil && ep.User != "" {
+		return ep.User
+	}
+	return "git"
+}
+
+func defaultSSHKey() string {
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		path := expandHome(filepath.Join("~", ".ssh", name))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"note/cfg"
+	"strings"
+	"testing"
+)
+
+func TestRemoteAuthToken(t *testing.T) {
+	old := cfg.DefaultCfg.Git
+	t.Cleanup(func() { cfg.DefaultCfg.Git = old })
+
+	tests := []struct {
+		name     string
+		env      string // NOTE_GIT_TOKEN
+		config   string // github.token
+		password string // 旧配置中的 github.password
+		token    string // 镜像仓库单独配置的 token
+		want     string
+		wantErr  string
+	}{
+		{name: "环境变量", env: "e", config: "c", want: "e"},
+		{name: "配置", config: "c", password: "p", want: "c"},
+		{name: "旧配置", password: "p", want: "p"},
+		{name: "镜像仓库", env: "e", token: "r", want: "r"},
+		{name: "未设置", wantErr: EnvToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvToken, tt.env)
+			cfg.DefaultCfg.Git.Token = tt.config
+			cfg.DefaultCfg.Git.Password = tt.password
+			cfg.DefaultCfg.Git.User = ""
+
+			auth, err := remoteAuth("https://example.com/u/notes.git", "", AuthAuto, tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "github.token") {
+					t.Errorf("err = %v, want mention of %s and github.token", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			basic, ok := auth.(*http.BasicAuth)
+			if !ok || basic.Password != tt.want || basic.Username != "note" {
+				t.Errorf("auth = %#v, want token %q", auth, tt.want)
+			}
+		})
+	}
+}
+
+// 本地路径的远程仓库不需要认证
+func TestRemoteAuthLocal(t *testing.T) {
+	auth, err := remoteAuth(t.TempDir(), "", AuthAuto, "")
+	if err != nil || auth != nil {
+		t.Errorf("remoteAuth(local) = %v, %v, want nil", auth, err)
+	}
+}
@@ -6,8 +6,7 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"note/cfg"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"note/shell"
 	"os"
 	"sort"
@@ -16,17 +15,25 @@ import (
 var DefaultBranch = "main"
 
 var HelpStr = "使用方法:" +
-	"\n	note add fileName/number // 新增/编辑文件,举例 note add ReadMe 或者 note 1" +
+	"\n	note add fileName/number/@id // 新增/编辑文件,举例 note add ReadMe 或者 note 1 或者 note @k3x9" +
 	"\n	note addDir dirName // 新增目录, 支持多级目录" +
-	"\n	note list/l // 列出存储目录结构" +
-	"\n	note view/v fileName // 查看文件内容" +
-	"\n	note s <keyWord> // 搜索关键字" +
-	"\n	note move srcPath targetPath //也支持重命名 note move java/a.go golang/b.go" +
+	"\n	note list/l [--tag tag] // 列出存储目录结构及笔记ID(@k3x9), 可按标签过滤" +
+	"\n	note tags // 列出所有标签" +
+	"\n	note tag <tag> // 列出带有该标签的笔记" +
+	"\n	note view/v fileName/number/@id // 查看文件内容" +
+	"\n	note s <keyWord> // 全文搜索关键字, 按相关度排序, 支持中文" +
+	"\n	note move srcPath/number/@id targetPath //也支持重命名 note move java/a.go golang/b.go" +
+	"\n	note links fileName/number/@id // 查看笔记中的 [[链接]]" +
+	"\n	note backlinks fileName/number/@id // 查看哪些笔记链接到了该笔记" +
+	"\n	note check // 检查失效的 [[链接]]" +
+	"\n	note encrypt path // 加密文件/目录下的笔记, 密码可通过环境变量 NOTE_PASSPHRASE 设置" +
+	"\n	note decrypt path // 解密文件/目录下的笔记" +
 	"\n	note init // 初始化仓库" +
 	"\n	note push // 推送到github仓库" +
-	"\n	note rm fileName // 删除目录/文件" +
+	"\n	note rm fileName/number/@id // 删除目录/文件" +
 	"\n	note log // 查看仓库提交日志" +
 	"\n	note lz path // 查看path目录下大文件" +
+	"\n	note mcp // 以 MCP(stdio) 服务方式提供笔记的列表/查看/搜索/编辑/移动/删除工具" +
 	""
 
 type GitHubClient struct {
@@ -34,22 +41,19 @@ type GitHubClient struct {
 	RemoteURL  string // GitHub仓库地址
 	SSHKeyPath string // SSH私钥路径
 	repo       *git.Repository
-	//auth       *ssh.PublicKeys
-	auth *http.BasicAuth
+	auth       transport.AuthMethod // ssh 私钥 / ssh-agent / https token, 首次使用时加载, 见 auth.go
+	authLoaded bool
+	auths      map[string]transport.AuthMethod // 各远程仓库的认证, 见 remote.go
+	lockFile   *os.File                        // 仓库锁, 见 lock.go
+	locks      int
 }
 
 func NewClient(localPath, remoteURL, sshKeyPath string) (*GitHubClient, error) {
-	auth := &http.BasicAuth{
-		Username: cfg.DefaultCfg.Git.User,
-		Password: cfg.DefaultCfg.Git.Password,
-	}
-
-	// 初始化客户端
+	// 初始化客户端, 认证在抓取/推送/克隆时才加载, 本地提交不依赖私钥和 ssh-agent
 	c := &GitHubClient{
 		LocalPath:  localPath,
 		RemoteURL:  remoteURL,
 		SSHKeyPath: sshKeyPath,
-		auth:       auth,
 	}
 
 	// 初始化/打开仓库
@@ -60,7 +64,6 @@ func NewClient(localPath, remoteURL, sshKeyPath string) (*GitHubClient, error) {
 
 	name, err := getCurrentBranch(c.repo)
 	DefaultBranch = name
-
 	return c, nil
 }
 
@@ -78,9 +81,13 @@ func (c *GitHubClient) initRepo() error {
 	repo, err := git.PlainOpen(c.LocalPath)
 	if err == git.ErrRepositoryNotExists {
 		// 克隆仓库
+		auth, authErr := c.defaultAuth()
+		if authErr != nil {
+			return authErr
+		}
 		repo, err = git.PlainClone(c.LocalPath, false, &git.CloneOptions{
 			URL:          c.RemoteURL,
-			Auth:         c.auth,
+			Auth:         auth,
 			SingleBranch: true,
 		})
 	}
@@ -100,7 +107,7 @@ func (c *GitHubClient) initRepo() error {
 			if err != nil {
 				fmt.Println("commit failed:", err)
 			}
-			err = addRemote(c.repo, "origin", c.RemoteURL)
+			err = addRemote(c.repo, DefaultRemote, c.RemoteURL)
 			if err != nil {
 				fmt.Println("add remote failed:", err)
 			}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"note/client/crypt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 加密笔记的合并: 用密码解密三方内容后按行合并, 再加密写回.
+// 有冲突时工作区暂存带冲突标记的明文, 冲突解决后提交合并前重新加密
+
+// 获取笔记密码, 由 lib 设置, 为空时加密笔记分叉一律按冲突处理
+var Passphrase func() (string, error)
+
+// 合并加密笔记, 返回是否有冲突
+func (c *GitHubClient) mergeEncrypted(path, base, ours, theirs string, write bool) (bool, error) {
+	abs := filepath.Join(c.LocalPath, path)
+	p, err := notePassphrase()
+	var plain []string
+	if err == nil {
+		plain, err = decryptAll(p, base, ours, theirs)
+	}
+	if err != nil {
+		if !write {
+			return true, nil
+		}
+		// 无法解密时把两个版本的密文都写入冲突标记, 由用户选择保留哪一个
+		fmt.Printf("%s 为加密笔记, 无法解密(%v), 请在冲突标记中保留其中一个版本\n", path, err)
+		merged := markerOurs + "\n" + ours + markerSep + "\n" + theirs + markerTheirs + "\n"
+		return true, os.WriteFile(abs, []byte(merged), 0644)
+	}
+
+	merged, conflict := merge3(plain[0], plain[1], plain[2])
+	if !write {
+		return conflict, nil
+	}
+	if conflict {
+		if err := c.addPendingEncrypt(path); err != nil {
+			return false, err
+		}
+		fmt.Printf("%s 为加密笔记, 解决冲突期间以明文保存在工作区, 合并提交前重新加密\n", path)
+		return true, os.WriteFile(abs, []byte(merged), 0644)
+	}
+	data, err := crypt.Encrypt([]byte(merged), p)
+	if err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(abs, data, 0644)
+}
+
+func notePassphrase() (string, error) {
+	if Passphrase == nil {
+		return "", errors.New("未设置密码")
+	}
+	return Passphrase()
+}
+
+// 依次解密, 未加密的内容原样返回
+func decryptAll(p string, contents ...string) ([]string, error) {
+	plain := make([]string, len(contents))
+	for i, s := range contents {
+		if !crypt.IsEncrypted([]byte(s)) {
+			plain[i] = s
+			continue
+		}
+		data, err := crypt.Decrypt([]byte(s), p)
+		if err != nil {
+			return nil, err
+		}
+		plain[i] = string(data)
+	}
+	return plain, nil
+}
+
+// 记录冲突期间以明文保存的加密笔记
+func (c *GitHubClient) addPendingEncrypt(path string) error {
+	f, err := os.OpenFile(c.pendingEncryptPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(path + "\n")
+	return err
+}
+
+// 冲突解决后重新加密明文暂存的笔记
+func (c *GitHubClient) encryptPending() error {
+	data, err := os.ReadFile(c.pendingEncryptPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p, err := notePassphrase()
+	if err != nil {
+		return fmt.Errorf("无法重新加密笔记: %v", err)
+	}
+	for _, path := range strings.Fields(string(data)) {
+		abs := filepath.Join(c.LocalPath, path)
+		plain, err := os.ReadFile(abs)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if crypt.IsEncrypted(plain) {
+			continue
+		}
+		enc, err := crypt.Encrypt(plain, p)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(abs, enc, 0644); err != nil {
+			return err
+		}
+	}
+	return os.Remove(c.pendingEncryptPath())
+}
+
+func (c *GitHubClient) pendingEncryptPath() string {
+	return filepath.Join(c.LocalPath, ".git", "NOTE_ENCRYPT_PENDING")
+}
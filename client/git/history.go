@@ -0,0 +1,165 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"io"
+	"time"
+)
+
+var ErrNoRevision = errors.New("没有找到该版本")
+
+// 修改过某个文件的一次提交
+type Revision struct {
+	Hash    plumbing.Hash
+	Path    string // 该提交中文件的路径, 移动过的文件在早期提交中是旧路径
+	When    time.Time
+	Author  string
+	Message string
+	Deleted bool // 该提交删除了文件
+}
+
+func (r Revision) Short() string {
+	return r.Hash.String()[:7]
+}
+
+// 修改过 path 的提交, 从新到旧, 跟随 note move 产生的重命名, 合并提交不计入
+func (c *GitHubClient) History(path string) ([]Revision, error) {
+	head, err := c.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	iter, err := c.repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	var revs []Revision
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if commit.NumParents() > 1 {
+			return nil
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		var parent *object.Tree
+		if commit.NumParents() == 1 {
+			p, err := commit.Parent(0)
+			if err != nil {
+				return err
+			}
+			if parent, err = p.Tree(); err != nil {
+				return err
+			}
+		}
+		changes, err := object.DiffTreeWithOptions(context.Background(), parent, tree, object.DefaultDiffTreeOptions)
+		if err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			if ch.To.Name != path && ch.From.Name != path {
+				continue
+			}
+			// 移走(如放入回收站)也算删除, 之前的提交里仍是原路径
+			rev := Revision{
+				Hash:    commit.Hash,
+				Path:    path,
+				When:    commit.Author.When,
+				Author:  commit.Author.Name,
+				Message: firstLine(commit.Message),
+				Deleted: ch.To.Name != path,
+			}
+			revs = append(revs, rev)
+			// 重命名之前的提交里使用旧路径
+			if !rev.Deleted && ch.From.Name != "" {
+				path = ch.From.Name
+			}
+			break
+		}
+		return nil
+	})
+	return revs, err
+}
+
+// 解析版本: 3 位以内的数字表示 note history 中的序号(1 为最新),
+// 否则按 git 版本解析, 如提交哈希前缀, HEAD~2. 返回对应提交及该提交中文件的路径
+func (c *GitHubClient) Resolve(path, rev string) (Revision, error) {
+	revs, err := c.History(path)
+	if err != nil {
+		return Revision{}, err
+	}
+	if n, ok := historyIndex(rev); ok {
+		if n < 1 || n > len(revs) {
+			return Revision{}, fmt.Errorf("%w: %s 只有 %d 个版本", ErrNoRevision, path, len(revs))
+		}
+		return revs[n-1], nil
+	}
+	hash, err := c.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return Revision{}, fmt.Errorf("%w: %s", ErrNoRevision, rev)
+	}
+	commit, err := c.repo.CommitObject(*hash)
+	if err != nil {
+		return Revision{}, err
+	}
+	// 最近一次修改早于或等于该提交的版本, 决定该提交中的文件路径和内容
+	for _, r := range revs {
+		old, err := c.repo.CommitObject(r.Hash)
+		if err != nil {
+			return Revision{}, err
+		}
+		if r.Hash == commit.Hash {
+			return r, nil
+		}
+		if ok, err := old.IsAncestor(commit); err != nil {
+			return Revision{}, err
+		} else if ok {
+			r.Hash = commit.Hash
+			r.When = commit.Author.When
+			r.Author = commit.Author.Name
+			r.Message = firstLine(commit.Message)
+			return r, nil
+		}
+	}
+	return Revision{}, fmt.Errorf("%w: %s 在 %s 中不存在", ErrNoRevision, path, rev)
+}
+
+// 提交哈希至少 4 位, 3 位以内的数字按序号处理
+func historyIndex(rev string) (int, bool) {
+	if rev == "" || len(rev) > 3 {
+		return 0, false
+	}
+	n := 0
+	for _, r := range rev {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+		n = n*10 + int(r-'0')
+	}
+	return n, true
+}
+
+// 某个版本中文件的原始内容, 加密的笔记需要调用方解密
+func (c *GitHubClient) FileAt(rev Revision) ([]byte, error) {
+	if rev.Deleted {
+		return nil, fmt.Errorf("%s 在 %s 中已被删除", rev.Path, rev.Short())
+	}
+	commit, err := c.repo.CommitObject(rev.Hash)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(rev.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s: %v", rev.Path, rev.Short(), err)
+	}
+	r, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
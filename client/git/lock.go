@@ -0,0 +1,61 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// 同一仓库的写操作串行执行, 后台同步和手工 note push / 编辑提交不会同时修改仓库.
+// 用 flock 锁住 .git/note.lock, 跨进程有效, 同一个 GitHubClient 内可重入
+
+const lockTimeout = 2 * time.Minute
+
+var ErrLocked = errors.New("仓库正被其它 note 进程使用")
+
+func (c *GitHubClient) lock() (func(), error) {
+	if c.locks > 0 {
+		c.locks++
+		return c.unlock, nil
+	}
+	f, err := os.OpenFile(filepath.Join(c.LocalPath, ".git", "note.lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	waiting := false
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w, 等待 %v 超时", ErrLocked, lockTimeout)
+		}
+		if !waiting {
+			waiting = true
+			fmt.Println("等待其它 note 进程(如后台同步)完成...")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.lockFile, c.locks = f, 1
+	return c.unlock, nil
+}
+
+func (c *GitHubClient) unlock() {
+	c.locks--
+	if c.locks > 0 {
+		return
+	}
+	syscall.Flock(int(c.lockFile.Fd()), syscall.LOCK_UN)
+	c.lockFile.Close()
+	c.lockFile = nil
+}
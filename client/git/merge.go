@@ -0,0 +1,145 @@
+package git
+
+import (
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"strings"
+)
+
+// 基于行的三方合并(diff3): 只有一方修改的区块自动采用修改方的内容,
+// 双方都修改且内容不同的区块写入冲突标记
+
+const (
+	markerOurs   = "<<<<<<< ours"
+	markerSep    = "======="
+	markerTheirs = ">>>>>>> theirs"
+)
+
+// 返回合并后的内容以及是否存在冲突
+func merge3(base, ours, theirs string) (string, bool) {
+	o := splitLines(base)
+	a := splitLines(ours)
+	b := splitLines(theirs)
+	matchA := matchLines(base, ours, len(o))
+	matchB := matchLines(base, theirs, len(o))
+
+	var out strings.Builder
+	conflict := false
+	emitConflict := func(partA, partB []string) {
+		conflict = true
+		out.WriteString(markerOurs + "\n")
+		writeLines(&out, partA)
+		out.WriteString(markerSep + "\n")
+		writeLines(&out, partB)
+		out.WriteString(markerTheirs + "\n")
+	}
+	resolve := func(partO, partA, partB []string) {
+		switch {
+		case equalLines(partA, partB), equalLines(partB, partO):
+			out.WriteString(strings.Join(partA, ""))
+		case equalLines(partA, partO):
+			out.WriteString(strings.Join(partB, ""))
+		default:
+			emitConflict(partA, partB)
+		}
+	}
+
+	io, ia, ib := 0, 0, 0
+	for {
+		// 三方一致的区块
+		n := 0
+		for io+n < len(o) && ia+n < len(a) && ib+n < len(b) &&
+			matchA[io+n] == ia+n && matchB[io+n] == ib+n {
+			n++
+		}
+		if n > 0 {
+			out.WriteString(strings.Join(o[io:io+n], ""))
+			io, ia, ib = io+n, ia+n, ib+n
+			continue
+		}
+
+		// 找到下一处三方一致的行, 中间的部分需要合并
+		next := io
+		for next < len(o) && (matchA[next] < 0 || matchB[next] < 0) {
+			next++
+		}
+		if next == len(o) {
+			if io < len(o) || ia < len(a) || ib < len(b) {
+				resolve(o[io:], a[ia:], b[ib:])
+			}
+			break
+		}
+		na, nb := matchA[next], matchB[next]
+		resolve(o[io:next], a[ia:na], b[ib:nb])
+		io, ia, ib = next, na, nb
+	}
+	return out.String(), conflict
+}
+
+// 计算 base 每一行在 other 中对应的行号, 没有对应时为 -1
+func matchLines(base, other string, n int) []int {
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	dmp := diffmatchpatch.New()
+	r1, r2, _ := dmp.DiffLinesToRunes(base, other)
+	diffs := dmp.DiffMainRunes(r1, r2, false)
+	i, j := 0, 0
+	for _, d := range diffs {
+		count := len([]rune(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for k := 0; k < count; k++ {
+				match[i+k] = j + k
+			}
+			i += count
+			j += count
+		case diffmatchpatch.DiffDelete:
+			i += count
+		case diffmatchpatch.DiffInsert:
+			j += count
+		}
+	}
+	return match
+}
+
+// 按行切分并保留换行符, 与 DiffLinesToRunes 的切分方式一致
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// 冲突区块的内容需要以换行结尾, 否则会和标记连在一起
+func writeLines(out *strings.Builder, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+		if !strings.HasSuffix(l, "\n") {
+			out.WriteString("\n")
+		}
+	}
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 是否包含冲突标记
+func hasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.TrimRight(line, "\r") == "<<<<<<<" {
+			return true
+		}
+	}
+	return false
+}
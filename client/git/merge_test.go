@@ -0,0 +1,42 @@
+package git
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflict           bool
+	}{
+		{name: "都未修改", base: "a\nb\n", ours: "a\nb\n", theirs: "a\nb\n", want: "a\nb\n"},
+		{name: "只有本地修改", base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n", want: "a\nB\nc\n"},
+		{name: "只有远程修改", base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\n", want: "a\nb\nC\n"},
+		{name: "修改不同的行", base: "a\nb\nc\nd\ne\n", ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n", want: "A\nb\nc\nd\nE\n"},
+		{name: "相同的修改", base: "a\nb\nc\n", ours: "a\nX\nc\n", theirs: "a\nX\nc\n", want: "a\nX\nc\n"},
+		{name: "两边追加", base: "a\n", ours: "a\nb\n", theirs: "a\nb\n", want: "a\nb\n"},
+		{name: "一边删除一边未改", base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nb\nc\n", want: "a\nc\n"},
+		{name: "空的祖先", base: "", ours: "", theirs: "new\n", want: "new\n"},
+		{
+			name: "同一行修改不同", base: "a\nb\nc\n", ours: "a\nours\nc\n", theirs: "a\ntheirs\nc\n",
+			want:     "a\n" + markerOurs + "\nours\n" + markerSep + "\ntheirs\n" + markerTheirs + "\nc\n",
+			conflict: true,
+		},
+		{
+			name: "末尾追加不同", base: "a\n", ours: "a\nb", theirs: "a\nc",
+			want:     "a\n" + markerOurs + "\nb\n" + markerSep + "\nc\n" + markerTheirs + "\n",
+			conflict: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflict := merge3(tt.base, tt.ours, tt.theirs)
+			if got != tt.want || conflict != tt.conflict {
+				t.Errorf("merge3() = %q, %v, want %q, %v", got, conflict, tt.want, tt.conflict)
+			}
+			if conflict != hasConflictMarkers(got) {
+				t.Errorf("hasConflictMarkers(%q) = %v", got, !conflict)
+			}
+		})
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"io/ioutil"
+	"note/cfg"
+	"note/client/crypt"
 	"note/shell"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -19,25 +24,27 @@ import (
 // 同步到远程仓库
 
 func (c *GitHubClient) Sync() ([]string, error) {
-	// 拉取最新变更
-	if err := c.pullChanges(); err != nil &&
-		!errors.Is(err, git.NoErrAlreadyUpToDate) &&
-		!errors.Is(err, git.ErrNonFastForwardUpdate) &&
-		err.Error() != "remote repository is empty" {
-		//if errors.Is(err, git.ErrNonFastForwardUpdate) {
-		//	// 执行 git pull --rebase
-		//	//err = exec.Command("git", "pull").Run()
-		//	fmt.Println("有冲突请执行手动执行cd ./db/ && git pull 然后解决冲突")
-		//}
-		// 检测冲突
-		if conflicts := c.detectConflicts(); len(conflicts) > 0 {
-			return conflicts, fmt.Errorf("检测到 %d 处冲突", len(conflicts))
-		}
+	unlock, err := c.lock()
+	if err != nil {
 		return nil, err
 	}
+	defer unlock()
+
+	// 上一次合并还有冲突没有提交
+	if conflicts, err := c.finishMerge(); err != nil || len(conflicts) > 0 {
+		return conflicts, err
+	}
 
-	// 推送本地变更
-	if err := c.pushChanges(); err != nil {
+	// 拉取最新变更并与本地三方合并
+	if err := c.fetch(); err != nil {
+		return nil, fmt.Errorf("抓取远程更新失败: %v", err)
+	}
+	if conflicts, err := c.merge(); err != nil || len(conflicts) > 0 {
+		return conflicts, err
+	}
+
+	// 推送本地变更到全部远程仓库
+	if err := c.pushAll(); err != nil {
 		if errors.Is(err, git.NoErrAlreadyUpToDate) {
 			fmt.Println("已经是最新版本")
 			return nil, nil
@@ -48,23 +55,284 @@ func (c *GitHubClient) Sync() ([]string, error) {
 	return nil, nil
 }
 
-func (c *GitHubClient) pullChanges() error {
-	w, err := c.repo.Worktree()
+// 抓取主仓库
+func (c *GitHubClient) fetch() error {
+	if err := c.ensureRemotes(); err != nil {
+		return err
+	}
+	auth, err := c.remoteAuth(PrimaryRemote())
 	if err != nil {
 		return err
 	}
+	err = c.repo.Fetch(&git.FetchOptions{
+		RemoteName: PrimaryRemote(),
+		Auth:       auth,
+	})
+	if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) ||
+		errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil
+	}
+	return err
+}
+
+// 合并远程分支: 能快进时直接快进, 分叉时做三方合并
+func (c *GitHubClient) merge() ([]string, error) {
+	remoteRef, err := c.repo.Reference(plumbing.NewRemoteReferenceName(PrimaryRemote(), DefaultBranch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// 远程还没有该分支
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 合并前先提交未提交的修改
+	if err := c.CommitChanges("合并前自动提交"); err != nil && !errors.Is(err, git.ErrEmptyCommit) {
+		return nil, err
+	}
+	headRef, err := c.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	if headRef.Hash() == remoteRef.Hash() {
+		return nil, nil
+	}
+
+	ours, err := c.repo.CommitObject(headRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := c.repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	// 远程已包含在本地中, 直接推送
+	if ok, err := theirs.IsAncestor(ours); err != nil || ok {
+		return nil, err
+	}
+
+	// 本地没有新提交, 快进到远程
+	if ok, err := ours.IsAncestor(theirs); err != nil {
+		return nil, err
+	} else if ok {
+		w, err := c.repo.Worktree()
+		if err != nil {
+			return nil, err
+		}
+		return nil, w.Reset(&git.ResetOptions{Commit: theirs.Hash, Mode: git.MergeReset})
+	}
+
+	return c.threeWayMerge(ours, theirs)
+}
+
+// 三方合并两个分叉的提交, 返回需要手工处理的冲突文件
+func (c *GitHubClient) threeWayMerge(ours, theirs *object.Commit) ([]string, error) {
+	conflicts, err := c.mergeFiles(ours, theirs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录正在合并的远程提交, 冲突解决后生成合并提交
+	if err := os.WriteFile(c.mergeHeadPath(), []byte(theirs.Hash.String()+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return conflicts, fmt.Errorf("检测到 %d 处冲突", len(conflicts))
+	}
+	return nil, c.commitMerge(theirs.Hash)
+}
+
+// 逐个文件三方合并, write 为 false 时只检查冲突, 不修改工作区
+func (c *GitHubClient) mergeFiles(ours, theirs *object.Commit, write bool) ([]string, error) {
+	baseFiles := make(map[string]*object.File)
+	bases, err := ours.MergeBase(theirs)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) > 0 {
+		if baseFiles, err = commitFiles(bases[0]); err != nil {
+			return nil, err
+		}
+	}
+	ourFiles, err := commitFiles(ours)
+	if err != nil {
+		return nil, err
+	}
+	theirFiles, err := commitFiles(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+	for _, files := range []map[string]*object.File{baseFiles, ourFiles, theirFiles} {
+		for p := range files {
+			paths[p] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var conflicts []string
+	for _, p := range sorted {
+		conflict, err := c.mergeFile(p, baseFiles[p], ourFiles[p], theirFiles[p], write)
+		if err != nil {
+			return nil, err
+		}
+		if conflict {
+			conflicts = append(conflicts, p)
+		}
+	}
+	return conflicts, nil
+}
 
-	return w.Pull(&git.PullOptions{
-		RemoteName:    "origin",
-		ReferenceName: plumbing.NewBranchReferenceName(DefaultBranch),
-		Auth:          c.auth,
+// 合并单个文件并写入工作区, 返回是否有冲突, write 为 false 时只检查冲突
+func (c *GitHubClient) mergeFile(path string, base, ours, theirs *object.File, write bool) (bool, error) {
+	abs := filepath.Join(c.LocalPath, path)
+	switch {
+	case sameFile(ours, theirs), sameFile(theirs, base):
+		// 远程没有修改, 保留本地
+		return false, nil
+	case !write && (sameFile(ours, base) || ours == nil || theirs == nil):
+		// 以下几种情况都不会产生冲突
+		return false, nil
+	case sameFile(ours, base):
+		// 只有远程修改, 直接采用远程
+		if theirs == nil {
+			return false, os.Remove(abs)
+		}
+		return false, writeBlob(abs, theirs)
+	case ours == nil || theirs == nil:
+		// 一方删除一方修改, 保留修改后的内容, 避免丢失笔记
+		fmt.Printf("%s 一方删除一方修改, 保留修改后的版本\n", path)
+		if theirs != nil {
+			return false, writeBlob(abs, theirs)
+		}
+		return false, nil
+	}
+
+	ourContent, err := ours.Contents()
+	if err != nil {
+		return false, err
+	}
+	theirContent, err := theirs.Contents()
+	if err != nil {
+		return false, err
+	}
+	var baseContent string
+	if base != nil {
+		if baseContent, err = base.Contents(); err != nil {
+			return false, err
+		}
+	}
+	if isBinary(ourContent) || isBinary(theirContent) {
+		if write {
+			fmt.Printf("%s 为二进制文件, 保留本地版本\n", path)
+		}
+		return false, nil
+	}
+	if crypt.IsEncrypted([]byte(ourContent)) || crypt.IsEncrypted([]byte(theirContent)) {
+		// 密文无法按行合并, 解密后再合并
+		return c.mergeEncrypted(path, baseContent, ourContent, theirContent, write)
+	}
+
+	merged, conflict := merge3(baseContent, ourContent, theirContent)
+	if !write {
+		return conflict, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(abs), 0755); err != nil {
+		return false, err
+	}
+	return conflict, os.WriteFile(abs, []byte(merged), 0644)
+}
+
+// 冲突全部解决后提交合并, 返回仍有冲突标记的文件
+func (c *GitHubClient) finishMerge() ([]string, error) {
+	data, err := os.ReadFile(c.mergeHeadPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if conflicts := c.detectConflicts(); len(conflicts) > 0 {
+		return conflicts, fmt.Errorf("仍有 %d 处冲突未解决", len(conflicts))
+	}
+	if err := c.encryptPending(); err != nil {
+		return nil, err
+	}
+	return nil, c.commitMerge(plumbing.NewHash(strings.TrimSpace(string(data))))
+}
+
+func (c *GitHubClient) commitMerge(theirs plumbing.Hash) error {
+	headRef, err := c.repo.Head()
+	if err != nil {
+		return err
+	}
+	w, err := c.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Add("."); err != nil {
+		return err
+	}
+	_, err = w.Commit(fmt.Sprintf("合并远程分支 %s/%s", PrimaryRemote(), DefaultBranch), &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Note Client",
+			Email: "client@notes.com",
+			When:  time.Now(),
+		},
+		Parents:           []plumbing.Hash{headRef.Hash(), theirs},
+		AllowEmptyCommits: true,
 	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(c.mergeHeadPath())
+}
+
+func (c *GitHubClient) mergeHeadPath() string {
+	return filepath.Join(c.LocalPath, ".git", "MERGE_HEAD")
 }
 
-func (c *GitHubClient) pushChanges() error {
-	return c.repo.Push(&git.PushOptions{
-		Auth: c.auth,
+func commitFiles(commit *object.Commit) (map[string]*object.File, error) {
+	files := make(map[string]*object.File)
+	iter, err := commit.Files()
+	if err != nil {
+		return nil, err
+	}
+	err = iter.ForEach(func(f *object.File) error {
+		files[f.Name] = f
+		return nil
 	})
+	return files, err
+}
+
+func sameFile(a, b *object.File) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+func writeBlob(path string, f *object.File) error {
+	content, err := f.Contents()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func isBinary(content string) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return strings.IndexByte(content, 0) >= 0
 }
 
 func (c *GitHubClient) pushU() error {
@@ -76,7 +344,7 @@ func (c *GitHubClient) pushU() error {
 	// 配置上游分支
 	cfg.Branches[DefaultBranch] = &config.Branch{
 		Name:   DefaultBranch,
-		Remote: "origin",
+		Remote: PrimaryRemote(),
 		Merge:  plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", DefaultBranch)),
 	}
 
@@ -94,6 +362,15 @@ func (c *GitHubClient) pushU() error {
 	return nil
 }
 
+// 只读取仓库的当前分支, 不克隆不初始化, 也不修改 DefaultBranch
+func CurrentBranch(localPath string) (string, error) {
+	repo, err := git.PlainOpen(localPath)
+	if err != nil {
+		return "", err
+	}
+	return getCurrentBranch(repo)
+}
+
 func getCurrentBranch(repo *git.Repository) (string, error) {
 	// 获取 HEAD 引用
 	ref, err := repo.Head()
@@ -107,17 +384,23 @@ func getCurrentBranch(repo *git.Repository) (string, error) {
 	return branchName, nil
 }
 
-// 检测冲突文件
+// 检测含有冲突标记的文件, 不限扩展名
 func (c *GitHubClient) detectConflicts() []string {
 	var conflicts []string
 
 	filepath.Walk(c.LocalPath, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() || !strings.HasSuffix(path, ".md") {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		content, _ := ioutil.ReadFile(path)
-		if strings.Contains(string(content), "<<<<<<<") {
+		if !isBinary(string(content)) && hasConflictMarkers(string(content)) {
 			relPath, _ := filepath.Rel(c.LocalPath, path)
 			conflicts = append(conflicts, relPath)
 		}
@@ -140,6 +423,12 @@ func (c *GitHubClient) ResolveConflict(filename, content string) error {
 // 提交变更到本地仓库
 
 func (c *GitHubClient) CommitChanges(message string) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	w, err := c.repo.Worktree()
 	if err != nil {
 		return err
@@ -177,46 +466,77 @@ func (c *GitHubClient) GetNote(filename string) (string, error) {
 	return string(content), nil
 }
 
+// 用配置的编辑器打开带冲突标记的文件, 直到冲突标记全部处理完或用户放弃
 func (c *GitHubClient) HandleConflictResolution(filename string) {
-	fmt.Printf("解决冲突文件: %s\n", filename)
-	fmt.Println("当前内容:")
-	content, _ := c.GetNote(filename)
-	fmt.Println(content)
-
-	fmt.Println("输入新内容 (输入 :wq 结束):")
-	var newContent strings.Builder
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == ":wq" {
-			break
-		}
-		newContent.WriteString(line + "\n")
+	editor := cfg.DefaultCfg.App.Editor
+	if editor == "" {
+		editor = "vi"
 	}
+	absPath := filepath.Join(c.LocalPath, filename)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("解决冲突文件: %s\n", filename)
+		cmd := exec.Command(editor, absPath)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Printf("打开编辑器失败: %v\n", err)
+			return
+		}
 
-	if err := c.ResolveConflict(filename, newContent.String()); err != nil {
-		fmt.Printf("解决冲突失败: %v\n", err)
-	} else {
-		fmt.Println("冲突已解决，请重新同步")
+		content, err := c.GetNote(filename)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if !hasConflictMarkers(content) {
+			fmt.Println("冲突已解决:", filename)
+			return
+		}
+		fmt.Print("文件中仍有冲突标记, 是否继续编辑? [Y/n] ")
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			// 没有可交互的输入时放弃, 避免反复打开编辑器
+			fmt.Println()
+			return
+		}
+		if strings.EqualFold(strings.TrimSpace(answer), "n") {
+			return
+		}
 	}
 }
 
 func (c *GitHubClient) Pull() error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := c.ensureRemotes(); err != nil {
+		return err
+	}
 	w, err := c.repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("获取工作树失败: %v", err)
 	}
 
-	// 获取远程引用信息
-	remote, err := c.repo.Remote("origin")
+	// 获取主仓库信息
+	name := PrimaryRemote()
+	remote, err := c.repo.Remote(name)
 	if err != nil {
 		return fmt.Errorf("获取远程仓库失败: %v", err)
 	}
+	auth, err := c.remoteAuth(name)
+	if err != nil {
+		return err
+	}
 
 	// 拉取前先 fetch 最新数据
 	if err := remote.Fetch(&git.FetchOptions{
-		Auth:     c.auth,
-		RefSpecs: []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Auth:     auth,
+		RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", name))},
 	}); err != nil && err != git.NoErrAlreadyUpToDate {
 		return fmt.Errorf("抓取远程更新失败: %v", err)
 	}
@@ -229,9 +549,9 @@ func (c *GitHubClient) Pull() error {
 
 	// 执行合并操作
 	pullOpts := &git.PullOptions{
-		RemoteName:    "origin",
+		RemoteName:    name,
 		ReferenceName: headRef.Name(),
-		Auth:          c.auth,
+		Auth:          auth,
 		Force:         true,
 	}
 
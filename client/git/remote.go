@@ -0,0 +1,211 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"note/cfg"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 多个远程仓库: github.url 对应 origin, github.remotes 中配置的和 note remote add 添加的作为镜像,
+// 同步时从主仓库(github.primary, 默认 origin)拉取合并, 然后推送到全部远程仓库
+
+const DefaultRemote = "origin"
+
+// 环境变量 NOTE_GIT_TOKEN_<NAME> 优先于镜像仓库配置中的 token
+const EnvRemoteToken = EnvToken + "_"
+
+type Remote struct {
+	Name       string
+	URL        string
+	Configured bool // 配置在 note_config.yaml 中
+	Primary    bool
+}
+
+// 拉取的主仓库
+func PrimaryRemote() string {
+	if p := cfg.DefaultCfg.Git.Primary; p != "" {
+		return p
+	}
+	return DefaultRemote
+}
+
+// 把配置文件中的镜像仓库同步到 .git/config, 地址变化时更新. 只在同步和 note remote 时调用, 打开仓库不修改配置
+func (c *GitHubClient) ensureRemotes() error {
+	for _, r := range cfg.DefaultCfg.Git.Remotes {
+		if r.Name == "" || r.URL == "" {
+			continue
+		}
+		remote, err := c.repo.Remote(r.Name)
+		if err == nil {
+			urls := remote.Config().URLs
+			if len(urls) > 0 && urls[0] == r.URL {
+				continue
+			}
+			if err := c.repo.DeleteRemote(r.Name); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, git.ErrRemoteNotFound) {
+			return err
+		}
+		if err := addRemote(c.repo, r.Name, r.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *GitHubClient) Remotes() ([]Remote, error) {
+	if err := c.ensureRemotes(); err != nil {
+		return nil, err
+	}
+	remotes, err := c.repo.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	configured := make(map[string]bool)
+	for _, r := range cfg.DefaultCfg.Git.Remotes {
+		configured[r.Name] = true
+	}
+	primary := PrimaryRemote()
+	var rs []Remote
+	for _, r := range remotes {
+		name := r.Config().Name
+		url := ""
+		if urls := r.Config().URLs; len(urls) > 0 {
+			url = urls[0]
+		}
+		rs = append(rs, Remote{
+			Name:       name,
+			URL:        url,
+			Configured: configured[name] || name == DefaultRemote && url == c.RemoteURL,
+			Primary:    name == primary,
+		})
+	}
+	// 主仓库排在最前面
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Primary != rs[j].Primary {
+			return rs[i].Primary
+		}
+		return rs[i].Name < rs[j].Name
+	})
+	return rs, nil
+}
+
+func (c *GitHubClient) AddRemote(name, url string) error {
+	if err := c.ensureRemotes(); err != nil {
+		return err
+	}
+	if _, err := transport.NewEndpoint(url); err != nil {
+		return fmt.Errorf("远程仓库地址解析失败: %v", err)
+	}
+	if _, err := c.repo.Remote(name); err == nil {
+		return fmt.Errorf("远程仓库 %s 已存在", name)
+	}
+	return addRemote(c.repo, name, url)
+}
+
+func (c *GitHubClient) RemoveRemote(name string) error {
+	for _, r := range cfg.DefaultCfg.Git.Remotes {
+		if r.Name == name {
+			return fmt.Errorf("远程仓库 %s 配置在 %s 中, 请从配置文件中删除", name, cfg.Path)
+		}
+	}
+	if name == PrimaryRemote() {
+		return fmt.Errorf("%s 是拉取的主仓库, 不能删除", name)
+	}
+	if err := c.repo.DeleteRemote(name); err != nil {
+		if errors.Is(err, git.ErrRemoteNotFound) {
+			return fmt.Errorf("远程仓库 %s 不存在", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// 远程仓库的认证, origin 使用全局配置, 镜像仓库可以单独配置认证方式和 token
+func (c *GitHubClient) remoteAuth(name string) (transport.AuthMethod, error) {
+	if auth, ok := c.auths[name]; ok {
+		return auth, nil
+	}
+	remote, err := c.repo.Remote(name)
+	if err != nil {
+		return nil, err
+	}
+	url := ""
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		url = urls[0]
+	}
+	var auth transport.AuthMethod
+	if name == DefaultRemote && url == c.RemoteURL {
+		if auth, err = c.defaultAuth(); err != nil {
+			return nil, err
+		}
+	} else {
+		method, token := "", os.Getenv(EnvRemoteToken+strings.ToUpper(name))
+		for _, r := range cfg.DefaultCfg.Git.Remotes {
+			if r.Name == name {
+				method = r.Auth
+				if token == "" {
+					token = r.Token
+				}
+			}
+		}
+		if auth, err = remoteAuth(url, "", method, token); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if c.auths == nil {
+		c.auths = make(map[string]transport.AuthMethod)
+	}
+	c.auths[name] = auth
+	return auth, nil
+}
+
+// 推送到全部远程仓库, 某一个失败不影响其它, 全部已是最新时返回 NoErrAlreadyUpToDate
+func (c *GitHubClient) pushAll() error {
+	remotes, err := c.repo.Remotes()
+	if err != nil {
+		return err
+	}
+	if len(remotes) == 0 {
+		return errors.New("没有配置远程仓库")
+	}
+	var errs []error
+	pushed := false
+	for _, r := range remotes {
+		name := r.Config().Name
+		auth, err := c.remoteAuth(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = c.repo.Push(&git.PushOptions{
+			RemoteName: name,
+			RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("refs/heads/%[1]s:refs/heads/%[1]s", DefaultBranch))},
+			Auth:       auth,
+		})
+		switch {
+		case errors.Is(err, git.NoErrAlreadyUpToDate):
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		default:
+			pushed = true
+			if len(remotes) > 1 {
+				fmt.Printf("已推送到 %s\n", name)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if !pushed {
+		return git.NoErrAlreadyUpToDate
+	}
+	return nil
+}
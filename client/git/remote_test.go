@@ -0,0 +1,250 @@
+package git
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"note/cfg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 测试用的空的裸仓库, 相当于 git init --bare
+func bareRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// 配置镜像仓库和主仓库, 测试结束后恢复
+func setRemotes(t *testing.T, primary string, remotes ...cfg.Remote) {
+	t.Helper()
+	old := cfg.DefaultCfg.Git
+	t.Cleanup(func() { cfg.DefaultCfg.Git = old })
+	cfg.DefaultCfg.Git.Remotes = remotes
+	cfg.DefaultCfg.Git.Primary = primary
+	cfg.DefaultCfg.Git.Auth = ""
+}
+
+// 新建本地仓库, origin 指向 origin, 带一个初始提交
+func newTestClient(t *testing.T, origin string) *GitHubClient {
+	t.Helper()
+	// CommitChanges 会切换工作目录
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	dir := t.TempDir() + "/"
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addRemote(repo, DefaultRemote, origin); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "readme", "init\n")
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add("."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Commit("init", &git.CommitOptions{Author: testAuthor()}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient(dir, origin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func testAuthor() *object.Signature {
+	return &object.Signature{Name: "test", Email: "test@notes.com", When: time.Now()}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func head(t *testing.T, c *GitHubClient) plumbing.Hash {
+	t.Helper()
+	ref, err := c.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref.Hash()
+}
+
+// 仓库中 DefaultBranch 分支指向的提交, 没有该分支时为零值
+func branchHash(t *testing.T, dir string) plumbing.Hash {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(DefaultBranch), true)
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return ref.Hash()
+}
+
+// 另一台设备克隆 url, 提交一个文件后推送回去
+func pushFromClone(t *testing.T, url, name, content string) plumbing.Hash {
+	t.Helper()
+	other := t.TempDir()
+	repo, err := git.PlainClone(other, false, &git.CloneOptions{URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, other, name, content)
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit("add "+name, &git.CommitOptions{Author: testAuthor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func remoteNames(t *testing.T, c *GitHubClient) map[string]bool {
+	t.Helper()
+	remotes, err := c.repo.Remotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, r := range remotes {
+		names[r.Config().Name] = true
+	}
+	return names
+}
+
+// 只打开仓库不修改 .git/config, 镜像仓库在同步或 note remote 时才加入
+func TestNewClientKeepsRemotes(t *testing.T) {
+	origin, mirror := bareRepo(t), bareRepo(t)
+	setRemotes(t, "", cfg.Remote{Name: "mirror", URL: mirror})
+	c := newTestClient(t, origin)
+
+	if names := remoteNames(t, c); len(names) != 1 || !names[DefaultRemote] {
+		t.Fatalf("remotes after NewClient = %v, want only origin", names)
+	}
+	rs, err := c.Remotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || !rs[0].Primary || rs[0].Name != DefaultRemote || rs[1].Name != "mirror" || !rs[1].Configured {
+		t.Errorf("Remotes() = %+v", rs)
+	}
+}
+
+func TestSyncPushesToAllRemotes(t *testing.T) {
+	origin, mirror := bareRepo(t), bareRepo(t)
+	setRemotes(t, "", cfg.Remote{Name: "mirror", URL: mirror})
+	c := newTestClient(t, origin)
+
+	writeFile(t, c.LocalPath, "a.md", "a\n")
+	if err := c.CommitChanges("add a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	want := head(t, c)
+	for name, dir := range map[string]string{"origin": origin, "mirror": mirror} {
+		if got := branchHash(t, dir); got != want {
+			t.Errorf("%s %s = %s, want %s", name, DefaultBranch, got, want)
+		}
+	}
+}
+
+// 只从主仓库拉取, 镜像仓库中的提交不会被拉下来
+func TestPullFromPrimary(t *testing.T) {
+	tests := []struct {
+		primary string
+		pulled  bool // 是否拉取到只在镜像仓库中的提交
+	}{
+		{primary: "", pulled: false},
+		{primary: "mirror", pulled: true},
+	}
+	for _, tt := range tests {
+		t.Run("primary="+tt.primary, func(t *testing.T) {
+			origin, mirror := bareRepo(t), bareRepo(t)
+			setRemotes(t, "", cfg.Remote{Name: "mirror", URL: mirror})
+			c := newTestClient(t, origin)
+			if _, err := c.Sync(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 另一台设备只推送到镜像仓库
+			theirs := pushFromClone(t, mirror, "b.md", "b\n")
+
+			cfg.DefaultCfg.Git.Primary = tt.primary
+			before := head(t, c)
+			if err := c.Pull(); err != nil {
+				t.Fatal(err)
+			}
+			got := head(t, c)
+			if tt.pulled && got != theirs {
+				t.Errorf("HEAD = %s, want %s from mirror", got, theirs)
+			}
+			if !tt.pulled && got != before {
+				t.Errorf("HEAD = %s, want unchanged %s", got, before)
+			}
+			if _, err := os.Stat(filepath.Join(c.LocalPath, "b.md")); (err == nil) != tt.pulled {
+				t.Errorf("b.md exists = %v, want %v", err == nil, tt.pulled)
+			}
+		})
+	}
+}
+
+// 分叉时的合并提交记录实际拉取的主仓库
+func TestMergeMessageNamesPrimary(t *testing.T) {
+	origin, mirror := bareRepo(t), bareRepo(t)
+	setRemotes(t, "", cfg.Remote{Name: "mirror", URL: mirror})
+	c := newTestClient(t, origin)
+	if _, err := c.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	pushFromClone(t, mirror, "b.md", "b\n")
+	writeFile(t, c.LocalPath, "a.md", "a\n")
+	if err := c.CommitChanges("add a"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.DefaultCfg.Git.Primary = "mirror"
+	if _, err := c.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := c.repo.CommitObject(head(t, c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "合并远程分支 mirror/" + DefaultBranch; commit.NumParents() != 2 || commit.Message != want {
+		t.Errorf("HEAD = %q with %d parents, want merge %q", commit.Message, commit.NumParents(), want)
+	}
+}
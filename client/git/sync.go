@@ -0,0 +1,199 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"strings"
+)
+
+var (
+	ErrMerging  = errors.New("有未解决的冲突, 请执行 note push 处理")
+	ErrConflict = errors.New("与远程仓库的修改有冲突, 请执行 note push 手工合并")
+	ErrDirty    = errors.New("有未提交的修改, 暂不合并远程更新")
+)
+
+// 本地分支与主仓库对应分支的差异
+type Status struct {
+	Remote  string // 如 origin/main
+	Tracked bool   // 远程是否已有该分支
+	Ahead   int    // 本地有, 远程没有的提交
+	Behind  int    // 远程有, 本地没有的提交
+	Dirty   bool   // 有未提交的修改
+	Merging bool   // 上一次合并的冲突还没有解决
+}
+
+// 抓取主仓库, 只更新远程分支, 不修改工作区
+func (c *GitHubClient) Fetch() error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.fetch()
+}
+
+func (c *GitHubClient) Status() (Status, error) {
+	st := Status{Remote: PrimaryRemote() + "/" + DefaultBranch}
+	if _, err := os.Stat(c.mergeHeadPath()); err == nil {
+		st.Merging = true
+	}
+	w, err := c.repo.Worktree()
+	if err != nil {
+		return st, err
+	}
+	ws, err := w.Status()
+	if err != nil {
+		return st, err
+	}
+	st.Dirty = !ws.IsClean()
+
+	head, err := c.repo.Head()
+	if err != nil {
+		return st, err
+	}
+	local, err := ancestors(c.repo, head.Hash())
+	if err != nil {
+		return st, err
+	}
+	remote := make(map[plumbing.Hash]bool)
+	ref, err := c.repo.Reference(plumbing.NewRemoteReferenceName(PrimaryRemote(), DefaultBranch), true)
+	if err == nil {
+		st.Tracked = true
+		if remote, err = ancestors(c.repo, ref.Hash()); err != nil {
+			return st, err
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return st, err
+	}
+	for h := range local {
+		if !remote[h] {
+			st.Ahead++
+		}
+	}
+	for h := range remote {
+		if !local[h] {
+			st.Behind++
+		}
+	}
+	return st, nil
+}
+
+// 从 hash 可以到达的全部提交
+func ancestors(repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	return seen, err
+}
+
+// 后台同步, 不会产生需要手工处理的冲突: 抓取主仓库, 能快进时快进, 分叉且能自动合并时合并,
+// 然后推送到全部远程仓库. 有未提交的修改且需要合并时不动工作区. 返回做了什么, 没有变化时为空
+func (c *GitHubClient) BackgroundSync() (string, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(c.mergeHeadPath()); err == nil {
+		return "", ErrMerging
+	}
+	if err := c.fetch(); err != nil {
+		return "", fmt.Errorf("抓取远程更新失败: %v", err)
+	}
+	st, err := c.Status()
+	if err != nil {
+		return "", err
+	}
+
+	if st.Behind > 0 && st.Dirty && c.onlyGitignoreChanged() {
+		// .gitignore 由程序自动追加, 直接提交, 不影响合并
+		if err := c.CommitChanges("更新 .gitignore"); err != nil {
+			return "", err
+		}
+		st.Dirty = false
+		st.Ahead++
+	}
+	if st.Behind > 0 && st.Dirty {
+		// 可能正在编辑笔记, 不修改工作区, 此时也无法推送到主仓库
+		return "", ErrDirty
+	}
+	var done []string
+	if st.Behind > 0 {
+		if st.Ahead > 0 {
+			if err := c.checkMerge(); err != nil {
+				return "", err
+			}
+		}
+		if conflicts, err := c.merge(); err != nil || len(conflicts) > 0 {
+			// checkMerge 已确认不会有冲突, 这里只是兜底
+			return "", errors.Join(err, ErrConflict)
+		}
+		done = append(done, fmt.Sprintf("合并了 %s 的 %d 个提交", st.Remote, st.Behind))
+	}
+
+	switch err := c.pushAll(); {
+	case errors.Is(err, git.NoErrAlreadyUpToDate):
+	case err != nil:
+		return strings.Join(done, ", "), fmt.Errorf("推送失败: %v", err)
+	default:
+		done = append(done, "已推送")
+	}
+	return strings.Join(done, ", "), nil
+}
+
+// 分叉时先检查三方合并是否会有冲突, 有冲突时留给 note push 手工处理
+func (c *GitHubClient) checkMerge() error {
+	head, err := c.repo.Head()
+	if err != nil {
+		return err
+	}
+	ref, err := c.repo.Reference(plumbing.NewRemoteReferenceName(PrimaryRemote(), DefaultBranch), true)
+	if err != nil {
+		return err
+	}
+	ours, err := c.repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	theirs, err := c.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return err
+	}
+	conflicts, err := c.mergeFiles(ours, theirs, false)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrConflict, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// 未提交的修改是否只有 .gitignore
+func (c *GitHubClient) onlyGitignoreChanged() bool {
+	w, err := c.repo.Worktree()
+	if err != nil {
+		return false
+	}
+	ws, err := w.Status()
+	if err != nil {
+		return false
+	}
+	for path, fs := range ws {
+		if path != ".gitignore" && (fs.Worktree != git.Unmodified || fs.Staging != git.Unmodified) {
+			return false
+		}
+	}
+	return true
+}
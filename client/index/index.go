@@ -0,0 +1,362 @@
+package index
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 全文倒排索引, 保存在存储目录的 .note/index 下(已加入 .gitignore, 不会提交)
+
+const (
+	Dir      = ".note/index"
+	fileName = "index.v2.gob"
+
+	// 旧版本的索引中可能有加密笔记解密后的内容, 打开时删除
+	legacyFile = "index.gob"
+
+	// BM25 参数
+	k1 = 1.2
+	b  = 0.75
+
+	// 超过该大小的文件不建索引
+	maxFileSize = 4 << 20
+)
+
+type Doc struct {
+	Path    string         // 相对存储目录的路径
+	ModTime int64          // 建索引时的修改时间, 用于判断是否需要重建
+	Size    int64          // 建索引时的文件大小
+	Length  int            // 词数
+	Terms   map[string]int // 词 -> 词频
+}
+
+type Index struct {
+	// 读取文件内容后的解码函数, 为空时直接使用原始内容, 返回错误时只索引文件名
+	Decode func(data []byte) ([]byte, error)
+
+	root     string
+	Docs     map[string]*Doc            // 路径 -> 文档
+	Postings map[string]map[string]bool // 词 -> 包含该词的文档路径
+	TotalLen int
+	dirty    bool
+}
+
+type Line struct {
+	Num  int
+	Text string
+}
+
+type Result struct {
+	Path  string
+	Score float64
+	Lines []Line // 命中的行
+	Terms []string
+}
+
+// 打开 root 下的索引, 不存在时返回空索引
+func Open(root string) (*Index, error) {
+	ix := &Index{
+		root:     root,
+		Docs:     make(map[string]*Doc),
+		Postings: make(map[string]map[string]bool),
+	}
+	if err := os.Remove(filepath.Join(root, Dir, legacyFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	data, err := os.ReadFile(ix.file())
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var docs map[string]*Doc
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&docs); err != nil {
+		// 索引损坏直接重建
+		ix.dirty = true
+		return ix, nil
+	}
+	for _, d := range docs {
+		ix.add(d)
+	}
+	return ix, nil
+}
+
+func (ix *Index) file() string {
+	return filepath.Join(ix.root, Dir, fileName)
+}
+
+// 保存索引, 先写临时文件再重命名, 避免写到一半被读到
+func (ix *Index) Save() error {
+	if !ix.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(ix.root, Dir), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ix.Docs); err != nil {
+		return err
+	}
+	tmp := ix.file() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ix.file()); err != nil {
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
+
+// 更新 rel 对应的文件或目录, 文件已不存在时从索引中删除
+func (ix *Index) Update(rel string) error {
+	rel = clean(rel)
+	path := filepath.Join(ix.root, rel)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		ix.Remove(rel)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ix.updateFile(rel, info)
+	}
+	return filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if p != path && hidden(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hidden(info.Name()) {
+			return nil
+		}
+		r, _ := filepath.Rel(ix.root, p)
+		return ix.updateFile(clean(r), info)
+	})
+}
+
+// 删除 rel 以及其下所有文件的索引
+func (ix *Index) Remove(rel string) {
+	rel = clean(rel)
+	for p := range ix.Docs {
+		if p == rel || strings.HasPrefix(p, rel+"/") {
+			ix.remove(p)
+		}
+	}
+}
+
+// 对比修改时间增量刷新整个存储目录, 处理 pull 或手工修改带来的变化
+func (ix *Index) Refresh() error {
+	seen := make(map[string]bool)
+	err := filepath.Walk(ix.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if p != ix.root && hidden(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hidden(info.Name()) {
+			return nil
+		}
+		r, _ := filepath.Rel(ix.root, p)
+		r = clean(r)
+		seen[r] = true
+		if d, ok := ix.Docs[r]; ok && d.ModTime == info.ModTime().UnixNano() && d.Size == info.Size() {
+			return nil
+		}
+		return ix.updateFile(r, info)
+	})
+	if err != nil {
+		return err
+	}
+	for p := range ix.Docs {
+		if !seen[p] {
+			ix.remove(p)
+		}
+	}
+	return nil
+}
+
+func (ix *Index) updateFile(rel string, info os.FileInfo) error {
+	if d, ok := ix.Docs[rel]; ok {
+		if d.ModTime == info.ModTime().UnixNano() && d.Size == info.Size() {
+			return nil
+		}
+		ix.remove(rel)
+	}
+	doc := &Doc{
+		Path:    rel,
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Terms:   make(map[string]int),
+	}
+	if info.Size() <= maxFileSize {
+		data, err := ix.read(rel)
+		if err != nil {
+			return err
+		}
+		if data != nil && !isBinary(data) {
+			ix.tokenizeDoc(doc, string(data))
+		}
+	}
+	// 文件名也参与索引
+	ix.tokenizeDoc(doc, rel)
+	ix.add(doc)
+	ix.dirty = true
+	return nil
+}
+
+func (ix *Index) tokenizeDoc(doc *Doc, text string) {
+	for _, t := range Tokenize(text) {
+		doc.Terms[t]++
+		doc.Length++
+	}
+}
+
+func (ix *Index) add(d *Doc) {
+	ix.Docs[d.Path] = d
+	ix.TotalLen += d.Length
+	for t := range d.Terms {
+		if ix.Postings[t] == nil {
+			ix.Postings[t] = make(map[string]bool)
+		}
+		ix.Postings[t][d.Path] = true
+	}
+}
+
+func (ix *Index) remove(rel string) {
+	d, ok := ix.Docs[rel]
+	if !ok {
+		return
+	}
+	for t := range d.Terms {
+		delete(ix.Postings[t], rel)
+		if len(ix.Postings[t]) == 0 {
+			delete(ix.Postings, t)
+		}
+	}
+	ix.TotalLen -= d.Length
+	delete(ix.Docs, rel)
+	ix.dirty = true
+}
+
+// 按 BM25 排序搜索, limit <= 0 表示不限制数量
+func (ix *Index) Search(query string, limit int) []Result {
+	terms := Tokenize(query)
+	if len(terms) == 0 || len(ix.Docs) == 0 {
+		return nil
+	}
+	n := float64(len(ix.Docs))
+	avgLen := float64(ix.TotalLen) / n
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, t := range terms {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		docs := ix.Postings[t]
+		df := float64(len(docs))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for p := range docs {
+			d := ix.Docs[p]
+			tf := float64(d.Terms[t])
+			scores[p] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(d.Length)/avgLen))
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for p, s := range scores {
+		results = append(results, Result{Path: p, Score: s})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	hl := highlightTerms(query)
+	for i := range results {
+		results[i].Terms = hl
+		results[i].Lines = ix.matchLines(results[i].Path, hl)
+	}
+	return results
+}
+
+// 取出文件中包含关键词的行
+func (ix *Index) matchLines(rel string, terms []string) []Line {
+	data, err := ix.read(rel)
+	if err != nil || data == nil || isBinary(data) {
+		return nil
+	}
+	var lines []Line
+	for i, line := range strings.Split(string(data), "\n") {
+		lower := strings.ToLower(line)
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				lines = append(lines, Line{Num: i + 1, Text: strings.TrimRight(line, "\r")})
+				break
+			}
+		}
+	}
+	return lines
+}
+
+// 读取文件内容, 解码失败时返回 nil, 只索引文件名
+func (ix *Index) read(rel string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(ix.root, rel))
+	if err != nil {
+		return nil, err
+	}
+	if ix.Decode == nil {
+		return data, nil
+	}
+	data, err = ix.Decode(data)
+	if err != nil {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func hidden(name string) bool {
+	// .git, .note 以及 .gitignore 等隐藏文件不参与索引
+	return strings.HasPrefix(name, ".")
+}
+
+func clean(rel string) string {
+	return filepath.ToSlash(filepath.Clean(rel))
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
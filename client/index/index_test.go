@@ -0,0 +1,193 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TotalLen 等于各文档词数之和, 倒排表和文档的词一一对应, 没有空的倒排表
+func checkIndex(t *testing.T, ix *Index) {
+	t.Helper()
+	total := 0
+	for p, d := range ix.Docs {
+		total += d.Length
+		for term := range d.Terms {
+			if !ix.Postings[term][p] {
+				t.Errorf("postings[%q] missing %s", term, p)
+			}
+		}
+	}
+	if total != ix.TotalLen {
+		t.Errorf("TotalLen = %d, want %d", ix.TotalLen, total)
+	}
+	for term, docs := range ix.Postings {
+		if len(docs) == 0 {
+			t.Errorf("postings[%q] is empty", term)
+		}
+		for p := range docs {
+			if d, ok := ix.Docs[p]; !ok || d.Terms[term] == 0 {
+				t.Errorf("postings[%q] has stale %s", term, p)
+			}
+		}
+	}
+}
+
+func paths(ix *Index) []string {
+	var ps []string
+	for p := range ix.Docs {
+		ps = append(ps, p)
+	}
+	sort.Strings(ps)
+	return ps
+}
+
+func TestUpdateRemove(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.md", "hello world")
+	writeFile(t, root, "dir/b.md", "hello 笔记")
+	writeFile(t, root, "dir/.hidden", "secret")
+	writeFile(t, root, ".note/ids.yaml", "secret")
+
+	ix, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{"a.md", "dir"} {
+		if err := ix.Update(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkIndex(t, ix)
+	if got := paths(ix); !reflect.DeepEqual(got, []string{"a.md", "dir/b.md"}) {
+		t.Fatalf("docs = %v", got)
+	}
+	// 正文和文件名: hello world a md
+	if d := ix.Docs["a.md"]; d.Length != 4 || d.Terms["hello"] != 1 || d.Terms["md"] != 1 {
+		t.Errorf("a.md = %+v", d)
+	}
+
+	ix.Remove("dir")
+	checkIndex(t, ix)
+	if _, ok := ix.Postings["笔记"]; ok {
+		t.Error("postings[笔记] left after Remove(dir)")
+	}
+	if len(ix.Postings["hello"]) != 1 || ix.TotalLen != ix.Docs["a.md"].Length {
+		t.Errorf("after Remove: hello = %v, TotalLen = %d", ix.Postings["hello"], ix.TotalLen)
+	}
+
+	// 文件删除后 Update 从索引中去掉
+	if err := os.Remove(filepath.Join(root, "a.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Update("a.md"); err != nil {
+		t.Fatal(err)
+	}
+	checkIndex(t, ix)
+	if len(ix.Docs) != 0 || len(ix.Postings) != 0 || ix.TotalLen != 0 {
+		t.Errorf("index not empty: %d docs, %d postings, TotalLen %d", len(ix.Docs), len(ix.Postings), ix.TotalLen)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.md", "hello world")
+	writeFile(t, root, "b.md", "rust")
+	ix, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改, 删除和新增文件, 重新打开索引后增量刷新
+	writeFile(t, root, "a.md", "hello go")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "a.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "b.md")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "c.md", "笔记")
+
+	ix, err = Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ix.Docs) != 2 {
+		t.Fatalf("reopened docs = %v", paths(ix))
+	}
+	if err := ix.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	checkIndex(t, ix)
+	if got := paths(ix); !reflect.DeepEqual(got, []string{"a.md", "c.md"}) {
+		t.Errorf("docs = %v", got)
+	}
+	for term, want := range map[string]bool{"go": true, "world": false, "rust": false, "笔记": true} {
+		if got := len(ix.Postings[term]) > 0; got != want {
+			t.Errorf("postings[%q] present = %v, want %v", term, got, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.md", "go go go\n读书笔记")
+	writeFile(t, root, "b.md", "go rust")
+	writeFile(t, root, "c.md", "go and a very long note about many other things that are not related at all")
+	writeFile(t, root, "d.md", "rust only")
+	ix, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{query: "go", want: []string{"a.md", "b.md", "c.md"}},
+		{query: "GO", limit: 2, want: []string{"a.md", "b.md"}},
+		{query: "go rust", want: []string{"b.md", "d.md", "a.md", "c.md"}}, // rust 更少见, 权重更高
+		{query: "笔记", want: []string{"a.md"}},
+		{query: "python", want: []string{}},
+		{query: " ", want: []string{}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, r := range ix.Search(tt.query, tt.limit) {
+			got = append(got, r.Path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	rs := ix.Search("笔记", 0)
+	if len(rs) != 1 || !reflect.DeepEqual(rs[0].Terms, []string{"笔记"}) || !reflect.DeepEqual(rs[0].Lines, []Line{{Num: 2, Text: "读书笔记"}}) {
+		t.Errorf("Search(笔记) = %+v", rs)
+	}
+}
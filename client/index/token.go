@@ -0,0 +1,77 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 分词: 英文/数字按连续字符切分并转小写, 中日韩文字切成单字和相邻双字,
+// 这样不依赖词典也能搜到 "笔记" 这样的词
+
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		for i := range cjk {
+			tokens = append(tokens, string(cjk[i]))
+			if i+1 < len(cjk) {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// 高亮/匹配行时使用的关键词: 中文只取双字(单字查询除外), 避免每个单字都命中
+func highlightTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	runs := Tokenize(query)
+	for i, t := range runs {
+		r := []rune(t)
+		if len(r) == 1 && isCJK(r[0]) {
+			// 前后存在以它开头/结尾的双字时跳过
+			if (i+1 < len(runs) && strings.HasPrefix(runs[i+1], t) && len([]rune(runs[i+1])) == 2) ||
+				(i > 0 && strings.HasSuffix(runs[i-1], t) && len([]rune(runs[i-1])) == 2) {
+				continue
+			}
+		}
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,45 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello, World", want: []string{"hello", "world"}},
+		{text: "v2_beta HTTP2", want: []string{"v2_beta", "http2"}},
+		{text: "笔记", want: []string{"笔", "笔记", "记"}},
+		{text: "Go语言", want: []string{"go", "语", "语言", "言"}},
+		{text: "学习Go和Rust", want: []string{"学", "学习", "习", "go", "和", "rust"}},
+		{text: "周会，纪要", want: []string{"周", "周会", "会", "纪", "纪要", "要"}},
+		{text: "メモ 메모", want: []string{"メ", "メモ", "モ", "메", "메모", "모"}},
+		{text: " ,.-", want: nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "笔记", want: []string{"笔记"}},
+		{query: "笔", want: []string{"笔"}},
+		{query: "语言笔记", want: []string{"语言", "言笔", "笔记"}},
+		{query: "笔记 Go 笔记", want: []string{"笔记", "go"}},
+		{query: "a 笔 b", want: []string{"a", "笔", "b"}},
+	}
+	for _, tt := range tests {
+		if got := highlightTerms(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("highlightTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package lib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"note/cfg"
+	"note/client/crypt"
+	"note/client/remind"
+	"note/shell"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// 加密存储模式, 开启后新建/编辑的笔记在提交前加密, 密码优先读取环境变量 NOTE_PASSPHRASE
+
+const EnvPassphrase = "NOTE_PASSPHRASE"
+
+var (
+	cachedPassphrase string
+	passphraseErr    error // 读取失败后不再重复提示
+)
+
+func encryptEnabled() bool {
+	return cfg.DefaultCfg.App.Encrypt
+}
+
+// 已加密的文件或加密模式下的笔记需要加密, todolist 由提醒服务在后台读取, 保持明文
+func shouldEncrypt(path string) bool {
+	if crypt.IsEncryptedFile(path) {
+		return true
+	}
+	return encryptEnabled() && RelPath(path) != remind.File
+}
+
+// 获取密码, 环境变量未设置时在终端提示输入.
+// 存储目录中已有加密笔记时用其中一篇校验密码, 没有时需要输入两次, 避免输错的密码加密出无法解密的笔记
+func passphrase() (string, error) {
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+	if passphraseErr != nil {
+		return "", passphraseErr
+	}
+	p, err := readPassphrase()
+	if err != nil {
+		// 读取或校验失败后不再重复提示
+		passphraseErr = err
+		return "", err
+	}
+	cachedPassphrase = p
+	return p, nil
+}
+
+func readPassphrase() (string, error) {
+	p := os.Getenv(EnvPassphrase)
+	fromEnv := p != ""
+	if !fromEnv {
+		var err error
+		if p, err = readPassword("请输入笔记密码: "); err != nil {
+			return "", err
+		}
+		if p == "" {
+			return "", errors.New("密码不能为空")
+		}
+	}
+	if rel, data := encryptedSample(); data != nil {
+		if _, err := crypt.Decrypt(data, p); err != nil {
+			return "", fmt.Errorf("密码错误, 无法解密 %s", rel)
+		}
+		return p, nil
+	}
+	if fromEnv {
+		return p, nil
+	}
+	again, err := readPassword("请再次输入笔记密码: ")
+	if err != nil {
+		return "", err
+	}
+	if again != p {
+		return "", errors.New("两次输入的密码不一致")
+	}
+	return p, nil
+}
+
+// 存储目录中的任意一篇加密笔记, 没有时返回 nil
+func encryptedSample() (string, []byte) {
+	var rel string
+	var data []byte
+	filepath.Walk(StorePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != StorePath {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !crypt.IsEncryptedFile(path) {
+			return nil
+		}
+		if data, err = os.ReadFile(path); err != nil {
+			data = nil
+			return nil
+		}
+		rel = RelPath(path)
+		return filepath.SkipAll
+	})
+	return rel, data
+}
+
+// 从终端读取密码, 输入时关闭回显
+func readPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("无法读取密码, 请设置环境变量 %s", EnvPassphrase)
+	}
+	defer tty.Close()
+
+	stty := func(args ...string) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = tty
+		cmd.Run()
+	}
+	fmt.Fprint(tty, prompt)
+	stty("-echo")
+	defer stty("echo")
+
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// 读取笔记时透明解密
+func decodeNote(data []byte) ([]byte, error) {
+	if !crypt.IsEncrypted(data) {
+		return data, nil
+	}
+	p, err := passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return crypt.Decrypt(data, p)
+}
+
+// 写入笔记前按需加密: 开启加密模式或原文件已加密时加密
+func encodeNote(path string, data []byte) ([]byte, error) {
+	if !shouldEncrypt(path) {
+		return data, nil
+	}
+	p, err := passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return crypt.Encrypt(data, p)
+}
+
+// 编辑加密笔记: 解密到临时文件, 编辑器退出后重新加密写回
+func editEncrypted(path string, skeleton []byte) bool {
+	dir, err := os.MkdirTemp("", "note-")
+	if err != nil {
+		shell.Log(err)
+		return false
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(path))
+
+	if data, err := os.ReadFile(path); err == nil {
+		plain, err := decodeNote(data)
+		if err != nil {
+			shell.Log(err)
+			return false
+		}
+		if err := os.WriteFile(tmp, plain, 0600); err != nil {
+			shell.Log(err)
+			return false
+		}
+	}
+
+	if !editFile(tmp, skeleton) {
+		return false
+	}
+	plain, err := os.ReadFile(tmp)
+	if os.IsNotExist(err) {
+		// 编辑器中删除了文件
+		os.Remove(path)
+		return true
+	}
+	if err != nil {
+		shell.Log(err)
+		return false
+	}
+	p, err := passphrase()
+	if err != nil {
+		shell.Log(err)
+		return false
+	}
+	data, err := crypt.Encrypt(plain, p)
+	if err != nil {
+		shell.Log(err)
+		return false
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		shell.Log(err)
+		return false
+	}
+	return true
+}
+
+// 加密文件或目录下的所有笔记并提交
+func EncryptNotes(fileName string) {
+	p, err := passphrase()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	convertNotes(fileName, "加密笔记: ", func(data []byte) ([]byte, bool, error) {
+		if crypt.IsEncrypted(data) {
+			return nil, false, nil
+		}
+		out, err := crypt.Encrypt(data, p)
+		return out, true, err
+	})
+}
+
+// 解密文件或目录下的所有笔记并提交
+func DecryptNotes(fileName string) {
+	p, err := passphrase()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	convertNotes(fileName, "解密笔记: ", func(data []byte) ([]byte, bool, error) {
+		if !crypt.IsEncrypted(data) {
+			return nil, false, nil
+		}
+		out, err := crypt.Decrypt(data, p)
+		return out, true, err
+	})
+}
+
+func convertNotes(fileName, title string, convert func(data []byte) ([]byte, bool, error)) {
+	root := ResolvePath(fileName)
+	var changed []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// 提醒服务在后台读取 todolist, 无法输入密码, 保持明文
+		if info.IsDir() || RelPath(path) == remind.File {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, ok, err := convert(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", RelPath(path), err)
+		}
+		if !ok {
+			return nil
+		}
+		if err := os.WriteFile(path, out, info.Mode()); err != nil {
+			return err
+		}
+		changed = append(changed, RelPath(path))
+		return nil
+	})
+	if err != nil {
+		shell.Log(err)
+	}
+	if len(changed) == 0 {
+		fmt.Println("没有需要处理的文件")
+		return
+	}
+	updateIndex(changed...)
+	CommitGit(title + RelPath(root))
+	fmt.Printf("已处理 %d 个文件\n", len(changed))
+}
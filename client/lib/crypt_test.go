@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// 存储目录中已有加密笔记时用它校验密码
+func TestPassphraseVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		sample  string // 已有加密笔记使用的密码, 为空时没有加密笔记
+		wantErr string
+	}{
+		{name: "没有加密笔记", env: "pw"},
+		{name: "密码正确", env: "pw", sample: "pw"},
+		{name: "密码错误", env: "pq", sample: "pw", wantErr: "密码错误, 无法解密 dir/secret.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			setPassphrase(t, tt.env)
+			if err := os.WriteFile(StorePath+"plain.md", []byte("明文\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.sample != "" {
+				if err := os.MkdirAll(StorePath+"dir", 0755); err != nil {
+					t.Fatal(err)
+				}
+				writeEncrypted(t, "dir/secret.md", "机密\n", tt.sample)
+			}
+
+			p, err := passphrase()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("passphrase() = %q, %v, want %q", p, err, tt.wantErr)
+				}
+				// 失败后不再重复读取
+				os.Setenv(EnvPassphrase, tt.sample)
+				if _, again := passphrase(); again != err {
+					t.Errorf("second passphrase() err = %v, want cached %v", again, err)
+				}
+				return
+			}
+			if err != nil || p != tt.env {
+				t.Errorf("passphrase() = %q, %v, want %q", p, err, tt.env)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"note/cfg"
+	"note/client/daemon"
+	"note/client/remind"
+	"note/shell"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 提醒服务的 pid 文件和日志, 只对本机有效, 不提交到仓库
+const (
+	daemonPid = ".note/daemon.pid"
+	daemonLog = ".note/daemon.log"
+)
+
+// 运行提醒服务直到收到 SIGTERM/SIGINT, logFile 为 true 时输出写入日志文件
+func Serve(logFile bool) error {
+	lock, err := daemon.Acquire(StorePath + daemonPid)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	ignoreDaemonFiles()
+	if logFile {
+		w := daemon.NewRotateWriter(StorePath + daemonLog)
+		defer w.Close()
+		done, err := redirectOutput(w)
+		if err != nil {
+			return err
+		}
+		defer done()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	fmt.Printf("提醒服务已启动, pid %d\n", os.Getpid())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		syncLoop(ctx)
+	}()
+	Loop(ctx)
+	// 等待正在进行的后台同步结束, 避免中途退出
+	wg.Wait()
+	fmt.Println("提醒服务已停止")
+	return nil
+}
+
+// 提醒服务在 .note 下生成的文件只对本机有效, 加入 .gitignore
+func ignoreDaemonFiles() {
+	for _, pattern := range []string{"/" + daemonPid, "/" + daemonLog + "*", "/" + remind.StateFile, "/" + remindLog, "/" + syncState} {
+		if err := ensureIgnored(pattern); err != nil {
+			shell.Log(err)
+		}
+	}
+}
+
+// 标准输出和标准错误都写入 w, 返回的函数用于恢复并等待写完
+func redirectOutput(w io.Writer) (func(), error) {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = pw, pw
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(w, r)
+		close(copied)
+	}()
+	return func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		pw.Close()
+		<-copied
+		r.Close()
+	}, nil
+}
+
+// 后台启动提醒服务, 等待其加锁成功后返回
+func StartDaemon() error {
+	if pid, ok := daemon.Running(StorePath + daemonPid); ok {
+		fmt.Printf("提醒服务已在运行, pid %d\n", pid)
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(StorePath+".note", 0755); err != nil {
+		return err
+	}
+	// 启动失败时的输出(如 panic)也记录到日志文件
+	log, err := os.OpenFile(StorePath+daemonLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	cmd := exec.Command(exe, "--config", cfg.Path, "server", "--log")
+	cmd.Stdout, cmd.Stderr = log, log
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	deadline := time.After(3 * time.Second)
+	for {
+		if pid, ok := daemon.Running(StorePath + daemonPid); ok && pid == cmd.Process.Pid {
+			fmt.Printf("提醒服务已启动, pid %d, 日志 %s\n", pid, StorePath+daemonLog)
+			return nil
+		}
+		select {
+		case err := <-exited:
+			return fmt.Errorf("提醒服务启动失败(%v), 查看日志 %s", err, StorePath+daemonLog)
+		case <-deadline:
+			return fmt.Errorf("等待提醒服务启动超时, 查看日志 %s", StorePath+daemonLog)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// 发送 SIGTERM 并等待退出, 超时后强制结束
+func StopDaemon() error {
+	pid, ok := daemon.Running(StorePath + daemonPid)
+	if !ok {
+		fmt.Println("提醒服务未运行")
+		return nil
+	}
+	if pid <= 0 {
+		return errors.New("无法读取提醒服务的 pid: " + StorePath + daemonPid)
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return err
+	}
+	for i := 0; i < 50; i++ {
+		if _, ok := daemon.Running(StorePath + daemonPid); !ok {
+			fmt.Printf("提醒服务已停止, pid %d\n", pid)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		return err
+	}
+	fmt.Printf("提醒服务未响应 SIGTERM, 已强制结束, pid %d\n", pid)
+	return nil
+}
+
+func RestartDaemon() error {
+	if err := StopDaemon(); err != nil {
+		return err
+	}
+	return StartDaemon()
+}
+
+func DaemonStatus() {
+	pid, ok := daemon.Running(StorePath + daemonPid)
+	if !ok {
+		fmt.Printf("%s未运行%s\n", shell.BrightYellow, shell.ResetAll)
+		return
+	}
+	since := ""
+	if info, err := os.Stat(StorePath + daemonPid); err == nil {
+		since = ", 启动于 " + info.ModTime().Format(remindLayout)
+	}
+	fmt.Printf("%s运行中%s pid %d%s\n", shell.BrightGreen, shell.ResetAll, pid, since)
+	fmt.Println("日志:", StorePath+daemonLog)
+}
+
+// 输出 systemd 用户服务, install 为 true 时写入 ~/.config/systemd/user/note.service
+func SystemdUnit(install bool) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	config, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return err
+	}
+	unit := daemon.Unit(exe, config)
+	if !install {
+		fmt.Print(unit)
+		return nil
+	}
+	path, err := daemon.UnitPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return err
+	}
+	fmt.Println("已写入", path)
+	fmt.Println("执行 systemctl --user daemon-reload && systemctl --user enable --now note 启用")
+	return nil
+}
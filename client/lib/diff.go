@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"fmt"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"note/shell"
+	"strings"
+	"unicode"
+)
+
+// 单词级别的差异: 英文单词, 数字和连续空白各算一个词, 中文按字, 其余符号单独成词
+func wordDiff(a, b string) []diffmatchpatch.Diff {
+	dict := make(map[string]rune)
+	var words []string
+	encode := func(s string) []rune {
+		var rs []rune
+		for _, w := range splitWords(s) {
+			r, ok := dict[w]
+			if !ok {
+				// 跳过代理区, 保证转换成字符串后不会被替换
+				r = rune(len(words) + 1)
+				if r >= 0xD800 {
+					r += 0x800
+				}
+				dict[w] = r
+				words = append(words, w)
+			}
+			rs = append(rs, r)
+		}
+		return rs
+	}
+	ra, rb := encode(a), encode(b)
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffCleanupSemantic(dmp.DiffMainRunes(ra, rb, false))
+	for i, d := range diffs {
+		var sb strings.Builder
+		for _, r := range d.Text {
+			if r >= 0xE000 {
+				r -= 0x800
+			}
+			sb.WriteString(words[r-1])
+		}
+		diffs[i].Text = sb.String()
+	}
+	return diffs
+}
+
+func splitWords(s string) []string {
+	var words []string
+	start := -1
+	class := 0
+	for i, r := range s {
+		c := wordClass(r)
+		if start >= 0 && (c != class || c == 0) {
+			words = append(words, s[start:i])
+			start = -1
+		}
+		if start < 0 {
+			start, class = i, c
+		}
+	}
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// 0 表示单独成词
+func wordClass(r rune) int {
+	switch {
+	case r == '\n' || unicode.Is(unicode.Han, r):
+		return 0
+	case unicode.IsSpace(r):
+		return 1
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 2
+	default:
+		return 0
+	}
+}
+
+type diffLine struct {
+	text    string
+	changed bool
+	number  int // 在新内容中的行号
+}
+
+// 打印差异, 删除的部分红色, 新增的部分绿色, 只显示有修改的行及前后 context 行
+func printDiff(diffs []diffmatchpatch.Diff, context int) {
+	var lines []diffLine
+	cur := diffLine{number: 1}
+	number := 1
+	for _, d := range diffs {
+		color, bg := "", ""
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			color, bg = shell.BrightRed, shell.RedBg
+		case diffmatchpatch.DiffInsert:
+			color, bg = shell.BrightGreen, shell.GreenBg
+		}
+		parts := strings.Split(d.Text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				// 换行本身被删除或新增也算修改
+				cur.changed = cur.changed || color != ""
+				lines = append(lines, cur)
+				if d.Type != diffmatchpatch.DiffDelete {
+					number++
+				}
+				cur = diffLine{number: number}
+			}
+			if part == "" {
+				continue
+			}
+			if color != "" {
+				// 只有空白的修改加上背景色, 否则看不出来
+				if strings.TrimSpace(part) == "" {
+					cur.text += bg + part + shell.ResetAll
+				} else {
+					cur.text += color + part + shell.ResetAll
+				}
+				cur.changed = true
+			} else {
+				cur.text += part
+			}
+		}
+	}
+	if cur.text != "" || cur.changed {
+		lines = append(lines, cur)
+	}
+
+	show := make([]bool, len(lines))
+	for i, l := range lines {
+		if !l.changed {
+			continue
+		}
+		for j := max(0, i-context); j <= min(len(lines)-1, i+context); j++ {
+			show[j] = true
+		}
+	}
+	for i, l := range lines {
+		if !show[i] {
+			continue
+		}
+		if i == 0 || !show[i-1] {
+			fmt.Printf("%s@@ 第 %d 行 @@%s\n", shell.BrightCyan, l.number, shell.ResetAll)
+		}
+		fmt.Println(l.text)
+	}
+}
+
+func hasDiff(diffs []diffmatchpatch.Diff) bool {
+	for _, d := range diffs {
+		if d.Type != diffmatchpatch.DiffEqual {
+			return true
+		}
+	}
+	return false
+}
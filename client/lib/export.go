@@ -0,0 +1,402 @@
+package lib
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"note/client/crypt"
+	"note/client/link"
+	"note/client/markdown"
+	"note/client/meta"
+	"note/client/tui"
+	"note/shell"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// note export: 导出为 HTML 静态站点, Markdown 或纯文本, 笔记之间的链接改写为导出后的路径.
+// HTML 站点带有与 note l 一致的目录页, --single 时所有笔记和图片合并成一个 HTML 文件
+
+type ExportOptions struct {
+	Format string // html, md, txt
+	Out    string // 输出目录
+	Single bool   // 只生成一个自包含的 HTML 文件
+	Style  string // 代码着色样式
+}
+
+type exportFile struct {
+	node  *uiNode
+	out   string // 输出的相对路径
+	data  []byte
+	asset bool // 图片等二进制文件, 原样复制
+}
+
+type exporter struct {
+	opt      ExportOptions
+	index    string // 目录页的文件名
+	files    []*exportFile
+	byRel    map[string]*exportFile
+	resolver *link.Resolver
+}
+
+var wikiLinkRegex = regexp.MustCompile(`\[\[([^\[\]\n]+?)\]\]`)
+
+func ExportNotes(target string, opt ExportOptions) error {
+	switch opt.Format {
+	case "html", "md", "txt":
+	default:
+		return fmt.Errorf("不支持的格式 %s, 可选: html, md, txt", opt.Format)
+	}
+	if opt.Single && opt.Format != "html" {
+		return errors.New("--single 只支持 html 格式")
+	}
+	if err := checkStyle(opt.Style); err != nil {
+		return err
+	}
+	if opt.Style == "" {
+		opt.Style = markdown.DefaultHTMLStyle
+	}
+	match, strip, name, err := exportScope(target)
+	if err != nil {
+		return err
+	}
+
+	e := &exporter{opt: opt, byRel: make(map[string]*exportFile)}
+	root, files := loadTree()
+	for _, n := range files {
+		if !match(n.rel) {
+			continue
+		}
+		data, err := os.ReadFile(StorePath + n.rel)
+		if err != nil {
+			return err
+		}
+		// 导出是为了分享, 加密笔记不解密
+		if crypt.IsEncrypted(data) {
+			fmt.Printf("%s跳过加密笔记 %s%s\n", shell.BrightYellow, n.rel, shell.ResetAll)
+			continue
+		}
+		f := &exportFile{node: n, data: data, asset: isBinary(data)}
+		if f.asset && opt.Format == "txt" {
+			continue
+		}
+		f.out = exportName(strings.TrimPrefix(n.rel, strip), opt.Format, f.asset)
+		e.files = append(e.files, f)
+		e.byRel[n.rel] = f
+	}
+	if len(e.files) == 0 {
+		return errors.New("没有可导出的笔记")
+	}
+	if e.resolver, err = link.NewResolver(StorePath); err != nil {
+		return err
+	}
+	// 导出的笔记中有 index.md 时目录页换个名字
+	e.index = "index.html"
+	if e.taken(e.index) {
+		e.index = "_index.html"
+	}
+
+	if opt.Single {
+		out := filepath.Join(opt.Out, name+".html")
+		if err := writeExport(out, []byte(e.single(root, name))); err != nil {
+			return err
+		}
+		fmt.Printf("已导出 %d 个文件到 %s\n", len(e.files), out)
+		return nil
+	}
+	for _, f := range e.files {
+		if err := writeExport(filepath.Join(opt.Out, f.out), e.render(f)); err != nil {
+			return err
+		}
+	}
+	if opt.Format == "html" {
+		page := pageHTML(name, "", "<h1>"+html.EscapeString(name)+"</h1>\n"+e.tree(root, false))
+		if err := writeExport(filepath.Join(opt.Out, e.index), []byte(page)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("已导出 %d 个文件到 %s\n", len(e.files), opt.Out)
+	return nil
+}
+
+// 导出范围: 为空时导出全部, 目录时导出目录下的所有笔记, 输出路径去掉目录前缀
+func exportScope(target string) (match func(rel string) bool, strip, name string, err error) {
+	all := func(string) bool { return true }
+	if target == "" || target == "." {
+		return all, "", "笔记", nil
+	}
+	path := ResolvePath(target)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("找不到 %s", target)
+	}
+	rel := RelPath(path)
+	if rel == "." {
+		return all, "", "笔记", nil
+	}
+	if info.IsDir() {
+		return func(r string) bool { return strings.HasPrefix(r, rel+"/") }, rel + "/", filepath.Base(rel), nil
+	}
+	if dir := filepath.ToSlash(filepath.Dir(rel)); dir != "." {
+		strip = dir + "/"
+	}
+	name = strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+	return func(r string) bool { return r == rel }, strip, name, nil
+}
+
+// Markdown 笔记替换扩展名, 其他文本文件追加扩展名, 避免 a.go 和 a.md 冲突
+func exportName(rel, format string, asset bool) string {
+	if asset || format == "md" {
+		return rel
+	}
+	switch strings.ToLower(filepath.Ext(rel)) {
+	case ".md", ".markdown", ".txt":
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel))
+	}
+	return rel + "." + format
+}
+
+func (e *exporter) taken(out string) bool {
+	for _, f := range e.files {
+		if f.out == out {
+			return true
+		}
+	}
+	return false
+}
+
+func writeExport(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (e *exporter) render(f *exportFile) []byte {
+	if f.asset {
+		return f.data
+	}
+	switch e.opt.Format {
+	case "md":
+		if markdown.Language(f.node.rel, f.data) != markdown.Markdown {
+			return f.data
+		}
+		return e.rewriteWikiLinks(f)
+	case "txt":
+		if markdown.Language(f.node.rel, f.data) != markdown.Markdown {
+			return f.data
+		}
+		return []byte(tui.Strip(markdown.Render(f.data, markdown.Options{Width: 80})))
+	}
+	title, body := e.noteHTML(f)
+	nav := fmt.Sprintf(`<a href="%s">目录</a> / %s`, e.relURL(path.Dir(f.out), e.index), html.EscapeString(f.node.rel))
+	return []byte(pageHTML(title, nav, body))
+}
+
+// Markdown 导出时 [[链接]] 改写为普通链接, 离开笔记工具也能跳转, 代码块中的不改
+func (e *exporter) rewriteWikiLinks(f *exportFile) []byte {
+	lines := strings.SplitAfter(string(f.data), "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if inFence {
+			continue
+		}
+		lines[i] = wikiLinkRegex.ReplaceAllStringFunc(line, func(s string) string {
+			target, text := s[2:len(s)-2], ""
+			if bar := strings.Index(target, "|"); bar >= 0 {
+				target, text = target[:bar], target[bar+1:]
+			}
+			href := e.link(f, strings.TrimSpace(target), markdown.InlineWikiLink)
+			if href == "" {
+				return s
+			}
+			if text == "" {
+				text = target
+			}
+			return "[" + text + "](" + href + ")"
+		})
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// 笔记正文的 HTML 和标题
+func (e *exporter) noteHTML(f *exportFile) (string, string) {
+	title := f.node.name
+	var sb strings.Builder
+	switch lang := markdown.Language(f.node.rel, f.data); lang {
+	case markdown.Markdown:
+		m, body, _ := meta.Parse(f.data)
+		if t := meta.Title(f.data); t != "" {
+			title = t
+		}
+		if m != nil && (len(m.Tags) > 0 || m.Updated != "") {
+			var info []string
+			if len(m.Tags) > 0 {
+				info = append(info, "标签: "+strings.Join(m.Tags, ", "))
+			}
+			if m.Updated != "" {
+				info = append(info, "更新于 "+m.Updated)
+			}
+			sb.WriteString(`<p class="meta">` + html.EscapeString(strings.Join(info, " · ")) + "</p>\n")
+		}
+		sb.WriteString(markdown.RenderHTML(body, markdown.HTMLOptions{
+			Style: e.opt.Style,
+			Link:  func(u string, kind markdown.InlineKind) string { return e.link(f, u, kind) },
+		}))
+	case markdown.Text:
+		sb.WriteString("<pre>" + html.EscapeString(string(f.data)) + "</pre>\n")
+	default:
+		sb.WriteString(markdown.HighlightHTML(string(f.data), lang, e.opt.Style))
+	}
+	return title, sb.String()
+}
+
+// 改写链接: [[目标]] 按笔记链接解析, 相对路径指向导出的文件时改为导出后的路径, 其他链接不变.
+// wiki 链接找不到导出的笔记时返回空字符串
+func (e *exporter) link(from *exportFile, u string, kind markdown.InlineKind) string {
+	var to *exportFile
+	fragment := ""
+	if kind == markdown.InlineWikiLink {
+		if i := strings.Index(u, "#"); i > 0 {
+			fragment = markdown.Slug(u[i+1:])
+		}
+		if rel, ok := e.resolver.Resolve(u); ok {
+			to = e.byRel[rel]
+		}
+		if to == nil {
+			return ""
+		}
+	} else {
+		var p string
+		var ok bool
+		if p, fragment, ok = localTarget(from.node.rel, u); !ok {
+			return u
+		}
+		if to = e.byRel[p]; to == nil {
+			return u
+		}
+	}
+
+	if e.opt.Single {
+		if to.asset {
+			return "data:" + http.DetectContentType(to.data) + ";base64," + base64.StdEncoding.EncodeToString(to.data)
+		}
+		if fragment != "" {
+			return "#" + fragment
+		}
+		return "#" + noteAnchor(to.node.rel)
+	}
+	href := e.relURL(path.Dir(from.out), to.out)
+	if fragment != "" {
+		href += "#" + fragment
+	}
+	return href
+}
+
+// 相对链接在存储目录中指向的路径, 以 / 开头时相对存储目录. 外部链接和页内锚点返回 false
+func localTarget(fromRel, u string) (rel, fragment string, ok bool) {
+	if u == "" || strings.HasPrefix(u, "#") || strings.Contains(u, ":") {
+		return "", "", false
+	}
+	p := u
+	if i := strings.Index(p, "#"); i >= 0 {
+		p, fragment = p[:i], p[i+1:]
+	}
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	if strings.HasPrefix(p, "/") {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+	} else {
+		p = path.Join(path.Dir(fromRel), p)
+	}
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", "", false
+	}
+	return p, fragment, true
+}
+
+// 从目录 dir 到 target 的相对地址, 路径中的空格等字符会被转义
+func (e *exporter) relURL(dir, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(target))
+	if err != nil {
+		rel = target
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).String()
+}
+
+func noteAnchor(rel string) string {
+	return "note-" + markdown.Slug(rel)
+}
+
+// 目录页, 与 note l 的目录树和下标一致, 只包含导出的笔记. inPage 时链接到页面内的锚点
+func (e *exporter) tree(root *uiNode, inPage bool) string {
+	var walk func(n *uiNode) string
+	walk = func(n *uiNode) string {
+		var items strings.Builder
+		for _, c := range n.children {
+			index := `<span class="index">` + c.index + "</span>"
+			if c.dir {
+				if sub := walk(c); sub != "" {
+					items.WriteString("<li>" + index + html.EscapeString(c.name) + "/\n" + sub + "</li>\n")
+				}
+				continue
+			}
+			f := e.byRel[c.rel]
+			// 合并成一个页面时图片内嵌在笔记中, 不单独列出
+			if f == nil || inPage && f.asset {
+				continue
+			}
+			href := e.relURL(".", f.out)
+			if inPage {
+				href = "#" + noteAnchor(c.rel)
+			}
+			fmt.Fprintf(&items, "<li>%s<a href=\"%s\">%s</a></li>\n", index, html.EscapeString(href), html.EscapeString(c.name))
+		}
+		if items.Len() == 0 {
+			return ""
+		}
+		return "<ul>\n" + items.String() + "</ul>\n"
+	}
+	return `<nav class="tree">` + "\n" + walk(root) + "</nav>\n"
+}
+
+// 所有笔记合并为一个页面, 图片内嵌, 笔记之间的链接改为页面内锚点
+func (e *exporter) single(root *uiNode, name string) string {
+	var sb strings.Builder
+	sb.WriteString("<h1>" + html.EscapeString(name) + "</h1>\n")
+	sb.WriteString(e.tree(root, true))
+	for _, f := range e.files {
+		if f.asset {
+			continue
+		}
+		_, body := e.noteHTML(f)
+		fmt.Fprintf(&sb, "<hr>\n<section id=\"%s\">\n<p class=\"meta\">%s · <a href=\"#\">回到目录</a></p>\n%s</section>\n",
+			noteAnchor(f.node.rel), html.EscapeString(f.node.rel), body)
+	}
+	return pageHTML(name, "", sb.String())
+}
+
+// 完整的 HTML 页面, 样式内嵌, 不依赖外部文件
+func pageHTML(title, nav, body string) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n<style>\n" + markdown.CSS + "</style>\n</head>\n<body>\n<main>\n")
+	if nav != "" {
+		sb.WriteString("<p class=\"meta\">" + nav + "</p>\n")
+	}
+	sb.WriteString(body)
+	sb.WriteString("</main>\n</body>\n</html>\n")
+	return sb.String()
+}
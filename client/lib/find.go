@@ -0,0 +1,212 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/term"
+	"io"
+	"note/client/crypt"
+	"note/client/meta"
+	"note/client/tui"
+	"note/shell"
+	"os"
+	"time"
+)
+
+// note f: 按相对路径和标题模糊查找笔记, 终端中交互选择, 否则按得分输出
+
+// 读取标题时最多读取的字节数
+const titleLimit = 4096
+
+type findItem struct {
+	node  *uiNode
+	title string
+	text  string // 参与匹配的文本: 路径 + 标题
+}
+
+type FindOptions struct {
+	Open bool // 用编辑器打开选中的笔记
+	View bool // 查看选中的笔记
+}
+
+func findItems() []findItem {
+	_, files := loadTree()
+	items := make([]findItem, 0, len(files))
+	for _, n := range files {
+		title := noteTitle(n.rel)
+		text := n.rel
+		if title != "" {
+			text += " " + title
+		}
+		items = append(items, findItem{node: n, title: title, text: text})
+	}
+	return items
+}
+
+// 只读取开头部分, 加密笔记和代码文件没有标题
+func noteTitle(rel string) string {
+	if !meta.Supports(rel) {
+		return ""
+	}
+	f, err := os.Open(StorePath + rel)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, titleLimit))
+	if err != nil || crypt.IsEncrypted(data) {
+		return ""
+	}
+	return meta.Title(data)
+}
+
+func rankItems(query string, items []findItem) []tui.Match {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.text
+	}
+	return tui.Filter(query, texts)
+}
+
+func FindNote(query string, opt FindOptions) error {
+	items := findItems()
+	var selected *findItem
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		item, err := pickNote(query, items)
+		if err != nil || item == nil {
+			return err
+		}
+		selected = item
+	} else {
+		matches := rankItems(query, items)
+		if !opt.Open && !opt.View {
+			for _, m := range matches {
+				item := items[m.Index]
+				fmt.Printf("%s\t%s\t%s\n", item.node.index, item.node.rel, item.title)
+			}
+			return nil
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("没有匹配 %s 的笔记", query)
+		}
+		selected = &items[matches[0].Index]
+	}
+
+	switch {
+	case opt.Open:
+		editPath(StorePath + selected.node.rel)
+	case opt.View:
+		return viewPath(StorePath+selected.node.rel, ViewOptions{})
+	default:
+		fmt.Println(selected.node.rel)
+	}
+	return nil
+}
+
+// 交互选择, 取消时返回 nil
+func pickNote(query string, items []findItem) (*findItem, error) {
+	t, err := tui.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	matches := rankItems(query, items)
+	cursor, offset := 0, 0
+	previewFor, preview := -1, []string(nil)
+	draw := func() {
+		w, h := t.Size()
+		listHeight := max(1, h-2)
+		leftWidth := max(20, w/2)
+		if cursor < offset {
+			offset = cursor
+		}
+		if cursor >= offset+listHeight {
+			offset = cursor - listHeight + 1
+		}
+		if cursor < len(matches) && matches[cursor].Index != previewFor {
+			previewFor = matches[cursor].Index
+			preview = previewLines(items[previewFor].node)
+		} else if len(matches) == 0 {
+			previewFor, preview = -1, nil
+		}
+
+		lines := []string{fmt.Sprintf("%s>%s %s%s %s  %d/%d%s", shell.BrightYellow, shell.ResetAll, query, shell.Reverse+" "+shell.ResetAll, shell.BrightBlack, len(matches), len(items), shell.ResetAll)}
+		for i := 0; i < listHeight; i++ {
+			left := ""
+			if r := offset + i; r < len(matches) {
+				left = findRow(items[matches[r].Index], matches[r].Positions, r == cursor, leftWidth)
+			}
+			right := ""
+			if i < len(preview) {
+				right = preview[i]
+			}
+			lines = append(lines, tui.Pad(left, leftWidth)+shell.ResetAll+shell.BrightBlack+"│"+shell.ResetAll+tui.Truncate(right, max(0, w-leftWidth-1)))
+		}
+		lines = append(lines, shell.BrightBlack+"输入过滤  ↑/↓ 移动  Enter 选择  Esc 取消"+shell.ResetAll)
+		t.Draw(lines)
+	}
+
+	w, h := t.Size()
+	draw()
+	for {
+		k, err := t.ReadKey(200 * time.Millisecond)
+		if errors.Is(err, tui.ErrTimeout) {
+			if nw, nh := t.Size(); nw != w || nh != h {
+				w, h = nw, nh
+				draw()
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case k.Is(tui.KeyEsc), k.IsCtrl('c'), k.IsCtrl('g'):
+			return nil, nil
+		case k.Is(tui.KeyEnter):
+			if cursor < len(matches) {
+				return &items[matches[cursor].Index], nil
+			}
+		case k.Is(tui.KeyUp), k.IsCtrl('p'), k.IsCtrl('k'):
+			cursor = max(0, cursor-1)
+		case k.Is(tui.KeyDown), k.IsCtrl('n'), k.IsCtrl('j'):
+			cursor = max(0, min(len(matches)-1, cursor+1))
+		default:
+			if q := tui.EditLine(query, k); q != query {
+				query = q
+				matches = rankItems(query, items)
+				cursor, offset = 0, 0
+			}
+		}
+		draw()
+	}
+}
+
+// 路径和标题中匹配到的字符高亮
+func findRow(item findItem, positions []int, selected bool, width int) string {
+	n := item.node
+	if selected {
+		text := n.index + " " + n.rel
+		if item.title != "" {
+			text += "  " + item.title
+		}
+		return shell.Reverse + tui.Pad(text, width) + shell.ResetAll
+	}
+	// 匹配文本中路径后面隔一个空格是标题
+	var inPath, inTitle []int
+	titleStart := len(n.rel) + 1
+	for _, p := range positions {
+		if p < len(n.rel) {
+			inPath = append(inPath, p)
+		} else if p >= titleStart {
+			inTitle = append(inTitle, p-titleStart)
+		}
+	}
+	on, off := shell.BrightYellow+shell.Bold, shell.ResetAll
+	row := shell.BrightBlack + n.index + shell.ResetAll + " " + tui.Highlight(n.rel, inPath, on, off)
+	if item.title != "" {
+		row += "  " + shell.BrightBlack + tui.Highlight(item.title, inTitle, on, off+shell.BrightBlack) + shell.ResetAll
+	}
+	return row
+}
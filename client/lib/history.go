@@ -0,0 +1,194 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"note/client/git"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 拆分 note@rev, 开头的 @ 是笔记 ID, 不算版本
+func splitRev(arg string) (string, string) {
+	if i := strings.LastIndex(arg, "@"); i > 0 {
+		return arg[:i], arg[i+1:]
+	}
+	return arg, ""
+}
+
+// 笔记在仓库中的相对路径, 已删除的笔记也可以直接写路径
+func notePath(note string) (string, error) {
+	path := ResolvePath(note)
+	if path == "" {
+		return "", fmt.Errorf("没有找到笔记: %s", note)
+	}
+	return RelPath(path), nil
+}
+
+// 某个版本的笔记内容, 加密的笔记自动解密
+func noteAt(g *git.GitHubClient, path, rev string) (git.Revision, []byte, error) {
+	r, err := g.Resolve(path, rev)
+	if err != nil {
+		return r, nil, err
+	}
+	data, err := g.FileAt(r)
+	if err != nil {
+		return r, nil, err
+	}
+	data, err = decodeNote(data)
+	return r, data, err
+}
+
+// 列出修改过该笔记的提交, 序号可以用作 show/diff/restore 的版本
+func ShowHistory(note string) error {
+	path, err := notePath(note)
+	if err != nil {
+		return err
+	}
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	revs, err := g.History(path)
+	if err != nil {
+		return err
+	}
+	if len(revs) == 0 {
+		fmt.Printf("%s 没有提交记录\n", path)
+		return nil
+	}
+	for i, r := range revs {
+		fmt.Printf("%3d %s%s%s %s %s%s%s (%s)",
+			i+1,
+			shell.BrightCyan, r.When.Format("2006-01-02 15:04:05"), shell.ResetAll,
+			r.Short(),
+			shell.BrightYellow, r.Message, shell.ResetAll,
+			r.Author,
+		)
+		if r.Deleted {
+			fmt.Printf(" %s已删除%s", shell.BrightRed, shell.ResetAll)
+		}
+		if r.Path != path {
+			fmt.Printf(" %s", r.Path)
+		}
+		fmt.Println()
+	}
+	if item := trashedItem(path); item != nil {
+		fmt.Printf("%s 在回收站中, 执行 note trash restore %s 恢复\n", item.Path, item.ID)
+	}
+	return nil
+}
+
+// 查看笔记的某个版本, arg 形如 a.md@2 / 3@abc1234 / @k3x9@HEAD~1, 省略版本时为最新提交的版本
+func ShowVersion(arg string) error {
+	note, rev := splitRev(arg)
+	if rev == "" {
+		rev = "1"
+	}
+	path, err := notePath(note)
+	if err != nil {
+		return err
+	}
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	r, data, err := noteAt(g, path, rev)
+	if err != nil {
+		return err
+	}
+	printNote(r.Path, data)
+	return nil
+}
+
+// 比较笔记的两个版本, 省略 rev1 时为上一个版本, 省略 rev2 时为当前文件
+func DiffNote(note string, revs []string) error {
+	path, err := notePath(note)
+	if err != nil {
+		return err
+	}
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	rev1 := "2"
+	if len(revs) > 0 {
+		rev1 = revs[0]
+	}
+	from, before, err := noteAt(g, path, rev1)
+	if err != nil {
+		return err
+	}
+	fromLabel := from.Path + "@" + from.Short()
+
+	var after []byte
+	toLabel := path
+	if len(revs) > 1 {
+		var to git.Revision
+		if to, after, err = noteAt(g, path, revs[1]); err != nil {
+			return err
+		}
+		toLabel = to.Path + "@" + to.Short()
+	} else {
+		after, err = os.ReadFile(StorePath + path)
+		if errors.Is(err, os.ErrNotExist) {
+			// 已删除的笔记与空内容比较
+			err, toLabel = nil, path+" (已删除)"
+		} else if err == nil {
+			after, err = decodeNote(after)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s--- %s%s\n", shell.BrightRed, fromLabel, shell.ResetAll)
+	fmt.Printf("%s+++ %s%s\n", shell.BrightGreen, toLabel, shell.ResetAll)
+	diffs := wordDiff(string(before), string(after))
+	if !hasDiff(diffs) {
+		fmt.Println("没有差异")
+		return nil
+	}
+	printDiff(diffs, 2)
+	return nil
+}
+
+// 用某个版本的内容覆盖当前笔记并提交, 已删除的笔记也可以恢复
+func RestoreNote(note, rev string) error {
+	path, err := notePath(note)
+	if err != nil {
+		return err
+	}
+	// 从历史版本恢复后, 回收站中的那一份就无法再恢复到原路径
+	if item := trashedItem(path); item != nil {
+		return fmt.Errorf("%s 在回收站中, 请执行 note trash restore %s 恢复后再恢复历史版本", item.Path, item.ID)
+	}
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	r, data, err := noteAt(g, path, rev)
+	if err != nil {
+		return err
+	}
+	full := StorePath + path
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	// 按当前的加密设置写回
+	if data, err = encodeNote(full, data); err != nil {
+		return err
+	}
+	if err := os.WriteFile(full, data, 0644); err != nil {
+		return err
+	}
+	updateIndex(path)
+	assignID(path)
+	if err := Commit(fmt.Sprintf("恢复 %s 到 %s", path, r.Short())); err != nil {
+		return err
+	}
+	fmt.Printf("%s已将 %s 恢复到 %s%s\n", shell.BrightGreen, path, r.Short(), shell.ResetAll)
+	return nil
+}
@@ -0,0 +1,125 @@
+package lib
+
+import (
+	gogit "github.com/go-git/go-git/v5"
+	"note/client/git"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 新建临时的存储目录仓库并切换到该目录, 和 Commit 提交时一样, 测试结束后恢复存储目录和工作目录
+func setupStore(t *testing.T) *git.GitHubClient {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldURL := StorePath, RemoteURL
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		StorePath, RemoteURL = oldStore, oldURL
+	})
+	StorePath, RemoteURL = t.TempDir()+"/", ""
+	if _, err := gogit.PlainInit(StorePath, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(StorePath); err != nil {
+		t.Fatal(err)
+	}
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func writeNote(t *testing.T, rel, content, message string) {
+	t.Helper()
+	path := filepath.Join(StorePath, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Commit(message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 移动后的笔记仍能看到移动前的历史, 早期版本使用旧路径
+func TestHistoryAcrossMove(t *testing.T) {
+	g := setupStore(t)
+	writeNote(t, "a.md", "v1\n", "新建")
+	writeNote(t, "a.md", "v2\n", "修改")
+	if err := MoveNote("a.md", "dir/b.md"); err != nil {
+		t.Fatal(err)
+	}
+	writeNote(t, "dir/b.md", "v3\n", "移动后修改")
+
+	revs, err := g.History("dir/b.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		message string
+		path    string
+		content string
+	}{
+		{message: "移动后修改", path: "dir/b.md", content: "v3\n"},
+		{message: "移动文件: from a.md to dir/b.md", path: "dir/b.md", content: "v2\n"},
+		{message: "修改", path: "a.md", content: "v2\n"},
+		{message: "新建", path: "a.md", content: "v1\n"},
+	}
+	if len(revs) != len(tests) {
+		t.Fatalf("History() = %d revisions %+v, want %d", len(revs), revs, len(tests))
+	}
+	for i, tt := range tests {
+		r := revs[i]
+		if r.Message != tt.message || r.Path != tt.path || r.Deleted {
+			t.Errorf("revs[%d] = %q %s deleted=%v, want %q %s", i, r.Message, r.Path, r.Deleted, tt.message, tt.path)
+			continue
+		}
+		data, err := g.FileAt(r)
+		if err != nil {
+			t.Errorf("FileAt(revs[%d]) err = %v", i, err)
+			continue
+		}
+		if string(data) != tt.content {
+			t.Errorf("FileAt(revs[%d]) = %q, want %q", i, data, tt.content)
+		}
+	}
+
+	// 序号和旧路径的版本都能解析
+	r, err := g.Resolve("dir/b.md", "3")
+	if err != nil || r.Path != "a.md" {
+		t.Errorf("Resolve(3) = %+v, %v, want a.md", r, err)
+	}
+}
+
+// 放入回收站的笔记, 删除的提交也出现在历史中
+func TestHistoryTrashed(t *testing.T) {
+	g := setupStore(t)
+	writeNote(t, "a.md", "v1\n", "新建")
+	if _, err := trashNote("a.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := g.History("a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("History() = %+v, want 2 revisions", revs)
+	}
+	if r := revs[0]; !r.Deleted || r.Message != "删除文件: a.md" || r.Path != "a.md" {
+		t.Errorf("revs[0] = %+v, want deleted", r)
+	}
+	if data, err := g.FileAt(revs[1]); err != nil || string(data) != "v1\n" {
+		t.Errorf("FileAt(revs[1]) = %q, %v, want v1", data, err)
+	}
+	if trashedItem("a.md") == nil {
+		t.Error("trashedItem(a.md) = nil")
+	}
+}
@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"note/client/meta"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 列出目录树, 每个笔记后面显示固定 ID, tag 不为空时按标签过滤
+func List(tag string) {
+	shell.InitWith(StorePath, TreeOptions(tag))
+}
+
+// 目录树选项: 在文件名后显示笔记 ID, 只读取不分配, ID 在新建和移动笔记时分配
+func TreeOptions(tag string) shell.TreeOptions {
+	ids, err := meta.LoadIDs(StorePath)
+	if err != nil {
+		shell.Log(err)
+		return shell.TreeOptions{Keep: tagFilter(tag)}
+	}
+	return shell.TreeOptions{
+		Keep: tagFilter(tag),
+		Label: func(path string) string {
+			if id := ids.ID(RelPath(path)); id != "" {
+				return "@" + id
+			}
+			return ""
+		},
+	}
+}
+
+// 根据 ID 查找笔记相对路径
+func lookupID(id string) (string, bool) {
+	ids, err := meta.LoadIDs(StorePath)
+	if err != nil {
+		return "", false
+	}
+	return ids.Path(id)
+}
+
+// 以下在笔记新增/移动/删除时维护 ID, 与变更一起提交
+
+func assignID(rel string) {
+	updateIDs(func(ids *meta.IDs) {
+		if info, err := os.Stat(StorePath + rel); err == nil && !info.IsDir() {
+			ids.Assign(rel)
+		}
+	})
+}
+
+// 移动后沿用原来的 ID, 还没有 ID 的笔记顺便分配
+func moveID(from, to string) {
+	updateIDs(func(ids *meta.IDs) {
+		ids.Move(from, to)
+		filepath.Walk(StorePath+to, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() {
+				ids.Assign(RelPath(path))
+			}
+			return nil
+		})
+	})
+}
+
+func removeID(rel string) {
+	updateIDs(func(ids *meta.IDs) { ids.Remove(rel) })
+}
+
+func updateIDs(fn func(ids *meta.IDs)) {
+	ids, err := meta.LoadIDs(StorePath)
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	fn(ids)
+	if err := ids.Save(); err != nil {
+		shell.Log(err)
+	}
+}
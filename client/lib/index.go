@@ -0,0 +1,187 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"note/client/crypt"
+	"note/client/index"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 搜索结果最多展示的文件数
+const searchLimit = 50
+
+var errEncryptedNote = errors.New("加密笔记")
+
+// 打开全文索引, 并确保索引目录不会被提交.
+// 索引以明文保存在本地, 加密笔记只索引文件名, 内容只在搜索时解密后加入内存中的索引
+func openIndex() (*index.Index, error) {
+	if err := ensureIgnored("/" + index.Dir + "/"); err != nil {
+		return nil, err
+	}
+	ix, err := index.Open(StorePath)
+	if err != nil {
+		return nil, err
+	}
+	ix.Decode = skipEncrypted
+	return ix, nil
+}
+
+func skipEncrypted(data []byte) ([]byte, error) {
+	if crypt.IsEncrypted(data) {
+		return nil, errEncryptedNote
+	}
+	return data, nil
+}
+
+// 笔记新增/修改后更新索引, paths 为相对存储目录的路径
+func updateIndex(paths ...string) {
+	ix, err := openIndex()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	for _, p := range paths {
+		if err := ix.Update(p); err != nil {
+			shell.Log(err)
+		}
+	}
+	if err := ix.Save(); err != nil {
+		shell.Log(err)
+	}
+}
+
+// 笔记删除后从索引中移除
+func removeIndex(paths ...string) {
+	ix, err := openIndex()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	for _, p := range paths {
+		ix.Remove(p)
+	}
+	if err := ix.Save(); err != nil {
+		shell.Log(err)
+	}
+}
+
+// 在存储目录的 .gitignore 中加入 pattern
+func ensureIgnored(pattern string) error {
+	path := filepath.Join(StorePath, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return os.WriteFile(path, []byte(content+pattern+"\n"), 0644)
+}
+
+// 全文搜索, 按相关度排序, 每行前面带上目录树下标
+func searchNotes(keyWord string) ([]index.Result, error) {
+	ix, err := refreshedIndex()
+	if err != nil {
+		return nil, err
+	}
+	indexEncrypted(ix)
+	return ix.Search(keyWord, searchLimit), nil
+}
+
+// 网页中的全文搜索, 不解密加密笔记, 加密笔记只按文件名命中且不显示内容
+func searchPlainNotes(keyWord string) ([]index.Result, error) {
+	ix, err := refreshedIndex()
+	if err != nil {
+		return nil, err
+	}
+	return ix.Search(keyWord, searchLimit), nil
+}
+
+// 先增量刷新, pull 下来的或手工修改的文件也能搜到
+func refreshedIndex() (*index.Index, error) {
+	ix, err := openIndex()
+	if err != nil {
+		return nil, err
+	}
+	if err := ix.Refresh(); err != nil {
+		return nil, err
+	}
+	if err := ix.Save(); err != nil {
+		shell.Log(err)
+	}
+	return ix, nil
+}
+
+// 输入密码后把加密笔记解密的内容加入内存中的索引, 之后不能再保存该索引
+func indexEncrypted(ix *index.Index) {
+	var encrypted []string
+	for p := range ix.Docs {
+		if crypt.IsEncryptedFile(StorePath + p) {
+			encrypted = append(encrypted, p)
+		}
+	}
+	if len(encrypted) == 0 {
+		return
+	}
+	if _, err := passphrase(); err != nil {
+		shell.Log(fmt.Errorf("%d 篇加密笔记只按文件名搜索: %v", len(encrypted), err))
+		return
+	}
+	ix.Decode = decodeNote
+	for _, p := range encrypted {
+		// 先去掉只有文件名的索引, Update 才会重新读取内容
+		ix.Remove(p)
+		if err := ix.Update(p); err != nil {
+			shell.Log(err)
+		}
+	}
+}
+
+func formatResults(results []index.Result) []string {
+	Map := shell.GetValMap(StorePath)
+	var lines []string
+	for _, r := range results {
+		idx := Map[filepath.Join(StorePath, r.Path)]
+		re := termsRegex(r.Terms)
+		if len(r.Lines) == 0 {
+			// 只有文件名命中
+			lines = append(lines, fmt.Sprintf("%s %s%s%s", idx, shell.Magenta, r.Path, shell.ResetAll))
+			continue
+		}
+		for _, l := range r.Lines {
+			text := strings.TrimSpace(l.Text)
+			if re != nil {
+				text = re.ReplaceAllString(text, shell.BrightRed+"$0"+shell.ResetAll)
+			}
+			lines = append(lines, fmt.Sprintf("%s %s%s%s:%s%d%s: %s",
+				idx, shell.Magenta, r.Path, shell.ResetAll, shell.Green, l.Num, shell.ResetAll, text))
+		}
+	}
+	return lines
+}
+
+func termsRegex(terms []string) *regexp.Regexp {
+	if len(terms) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	re, err := regexp.Compile("(?i)" + strings.Join(quoted, "|"))
+	if err != nil {
+		return nil
+	}
+	return re
+}
@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"bytes"
+	"note/client/crypt"
+	"note/client/index"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 使用环境变量中的密码, 测试结束后清除缓存的密码
+func setPassphrase(t *testing.T, p string) {
+	t.Setenv(EnvPassphrase, p)
+	cachedPassphrase, passphraseErr = "", nil
+	t.Cleanup(func() { cachedPassphrase, passphraseErr = "", nil })
+}
+
+func writeEncrypted(t *testing.T, rel, plain, p string) {
+	t.Helper()
+	data, err := crypt.Encrypt([]byte(plain), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(StorePath+rel, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resultPaths(results []index.Result) []string {
+	var ps []string
+	for _, r := range results {
+		ps = append(ps, r.Path)
+	}
+	return ps
+}
+
+// 加密笔记的内容不写入本地索引, 只在命令行搜索时解密后在内存中搜索
+func TestSearchEncrypted(t *testing.T) {
+	setupStore(t)
+	setPassphrase(t, "pw")
+	legacy := filepath.Join(StorePath, index.Dir, "index.gob")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("机密"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(StorePath+"plain.md", []byte("hello 明文\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeEncrypted(t, "secret.md", "hello\n机密内容\n", "pw")
+
+	indexFiles := func() []byte {
+		var all []byte
+		files, _ := filepath.Glob(filepath.Join(StorePath, index.Dir, "*"))
+		for _, f := range files {
+			data, _ := os.ReadFile(f)
+			all = append(all, data...)
+		}
+		return all
+	}
+
+	rs, err := searchPlainNotes("机密")
+	if err != nil || len(rs) != 0 {
+		t.Errorf("searchPlainNotes(机密) = %v, %v, want none", resultPaths(rs), err)
+	}
+	if rs, _ := searchPlainNotes("secret"); len(rs) != 1 || rs[0].Lines != nil {
+		t.Errorf("searchPlainNotes(secret) = %+v, want file name only", rs)
+	}
+
+	rs, err = searchNotes("机密")
+	if err != nil || len(rs) != 1 || rs[0].Path != "secret.md" || len(rs[0].Lines) != 1 || rs[0].Lines[0].Text != "机密内容" {
+		t.Errorf("searchNotes(机密) = %+v, %v, want secret.md line 2", rs, err)
+	}
+	if rs, _ := searchNotes("hello"); len(rs) != 2 {
+		t.Errorf("searchNotes(hello) = %v, want both notes", resultPaths(rs))
+	}
+
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy index still exists: %v", err)
+	}
+	if data := indexFiles(); len(data) == 0 || bytes.Contains(data, []byte("机密")) || !bytes.Contains(data, []byte("明文")) {
+		t.Errorf("index on disk has encrypted content or misses plain notes")
+	}
+}
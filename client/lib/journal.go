@@ -0,0 +1,175 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alecthomas/chroma/quick"
+	"note/cfg"
+	"note/client/meta"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日记按日期保存为 <journal>/2026-10-17.md, 第一次打开时由模板生成, 见 journalSkeleton
+
+const (
+	dateLayout      = "2006-01-02"
+	journalTemplate = ".template.md"
+)
+
+var weekdays = []string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"}
+
+const defaultJournal = `## 今日工作
+
+## 遇到的问题
+
+## 明日计划
+`
+
+func journalDir() string {
+	dir := strings.Trim(cfg.DefaultCfg.App.Journal, "/")
+	if dir == "" {
+		dir = "journal"
+	}
+	return dir
+}
+
+func journalPath(day time.Time) string {
+	return StorePath + journalDir() + "/" + day.Format(dateLayout) + ".md"
+}
+
+// 打开某一天的日记, date 支持 today / yesterday / tomorrow / 2d(2 天前) / 2026-10-17 / 10-17
+func OpenJournal(date string) error {
+	day, err := parseDate(date, time.Now())
+	if err != nil {
+		return err
+	}
+	path := journalPath(day)
+	var skeleton []byte
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if skeleton, err = journalSkeleton(path, day); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+	}
+	if createNote(path, skeleton) {
+		CommitGit(filepath.Base(path))
+	}
+	return nil
+}
+
+func parseDate(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch s {
+	case "", "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && strings.HasSuffix(s, "d") && n >= 0 {
+		return today.AddDate(0, 0, -n), nil
+	}
+	if t, err := time.ParseInLocation(dateLayout, s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("01-02", s, time.Local); err == nil {
+		return t.AddDate(today.Year(), 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期 %s, 举例 today / yesterday / 2d / 2026-10-17 / 10-17", s)
+}
+
+// 依次使用 templates/journal 模板, 日记目录下的 .template.md 和默认模板
+func journalSkeleton(path string, day time.Time) ([]byte, error) {
+	data := newTemplateData(path, day.Format(dateLayout)+" "+weekdays[day.Weekday()], day)
+	file, err := findTemplate("journal")
+	if err != nil {
+		file = StorePath + journalDir() + "/" + journalTemplate
+	}
+	if _, err := os.Stat(file); err == nil {
+		return renderTemplate(file, data)
+	}
+	m := meta.New(path)
+	m.Title = data.Title
+	m.Tags = []string{"journal"}
+	return meta.Render(m, []byte("\n"+defaultJournal))
+}
+
+// 列出所有日记, week 为 true 时把本周的日记拼接后高亮输出
+func ListJournal(week bool) {
+	if week {
+		showWeek(time.Now())
+		return
+	}
+	days, err := journalDays()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	if len(days) == 0 {
+		fmt.Println("还没有日记, 执行 note today 开始写")
+		return
+	}
+	for i := len(days) - 1; i >= 0; i-- {
+		fmt.Printf("%s%s%s %s\n", shell.BrightYellow, days[i].Format(dateLayout), shell.ResetAll, weekdays[days[i].Weekday()])
+	}
+}
+
+// 日记目录下所有按日期命名的日记, 按日期升序
+func journalDays() ([]time.Time, error) {
+	entries, err := os.ReadDir(StorePath + journalDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".md")
+		if t, err := time.ParseInLocation(dateLayout, name, time.Local); err == nil && !e.IsDir() {
+			days = append(days, t)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+// 本周一到周日的日记
+func showWeek(now time.Time) {
+	offset := (int(now.Weekday()) + 6) % 7
+	monday := time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, time.Local)
+	var sb strings.Builder
+	for i := 0; i < 7; i++ {
+		day := monday.AddDate(0, 0, i)
+		data, err := os.ReadFile(journalPath(day))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			data, err = decodeNote(data)
+		}
+		if err != nil {
+			shell.Log(err)
+			continue
+		}
+		_, body, err := meta.Parse(data)
+		if err != nil {
+			body = data
+		}
+		fmt.Fprintf(&sb, "# %s %s\n\n%s\n\n", day.Format(dateLayout), weekdays[day.Weekday()], strings.TrimSpace(string(body)))
+	}
+	if sb.Len() == 0 {
+		fmt.Println("本周还没有日记")
+		return
+	}
+	quick.Highlight(os.Stdout, sb.String(), "markdown", "terminal256", "monokai")
+}
@@ -0,0 +1,213 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"note/client/crypt"
+	"note/client/link"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 列出笔记中的 [[链接]]
+func ShowLinks(fileName string) {
+	r, rel, ok := resolveNote(fileName)
+	if !ok {
+		return
+	}
+	data, err := readLinkSource(rel)
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	Map := shell.GetValMap(StorePath)
+	for _, l := range link.Parse(data) {
+		target, ok := r.Resolve(l.Target)
+		if !ok {
+			fmt.Printf("%d: [[%s]] %s(未找到)%s\n", l.Line, l.Target, shell.BrightRed, shell.ResetAll)
+			continue
+		}
+		fmt.Printf("%d: [[%s]] -> %s %s%s%s\n", l.Line, l.Target,
+			Map[filepath.Join(StorePath, target)], shell.Yellow, target, shell.ResetAll)
+	}
+}
+
+// 列出链接到该笔记的其他笔记
+func ShowBacklinks(fileName string) {
+	r, rel, ok := resolveNote(fileName)
+	if !ok {
+		return
+	}
+	Map := shell.GetValMap(StorePath)
+	var skipped []string
+	for _, src := range r.Files() {
+		data, err := readLinkSource(src)
+		if errors.Is(err, errEncryptedNote) {
+			skipped = append(skipped, src)
+			continue
+		}
+		if err != nil || isBinary(data) {
+			continue
+		}
+		for _, l := range link.Parse(data) {
+			if target, ok := r.Resolve(l.Target); ok && target == rel {
+				fmt.Printf("%s %s%s%s:%s%d%s: [[%s]]\n", Map[filepath.Join(StorePath, src)],
+					shell.Yellow, src, shell.ResetAll, shell.Green, l.Line, shell.ResetAll, l.Target)
+			}
+		}
+	}
+	printSkipped("未检查其中的链接", skipped)
+}
+
+// 检查所有笔记中的失效链接
+func CheckLinks() {
+	r, err := link.NewResolver(StorePath)
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	broken := 0
+	var skipped []string
+	for _, src := range r.Files() {
+		data, err := readLinkSource(src)
+		if errors.Is(err, errEncryptedNote) {
+			skipped = append(skipped, src)
+			continue
+		}
+		if err != nil || isBinary(data) {
+			continue
+		}
+		for _, l := range link.Parse(data) {
+			if _, ok := r.Resolve(l.Target); !ok {
+				broken++
+				fmt.Printf("%s%s%s:%s%d%s: [[%s]] %s(未找到)%s\n", shell.Yellow, src, shell.ResetAll,
+					shell.Green, l.Line, shell.ResetAll, l.Target, shell.BrightRed, shell.ResetAll)
+			}
+		}
+	}
+	printSkipped("未检查其中的链接", skipped)
+	if broken == 0 {
+		fmt.Println("没有失效的链接")
+		return
+	}
+	fmt.Printf("共 %d 个失效链接\n", broken)
+}
+
+func resolveNote(fileName string) (*link.Resolver, string, bool) {
+	r, err := link.NewResolver(StorePath)
+	if err != nil {
+		shell.Log(err)
+		return nil, "", false
+	}
+	path := ResolvePath(fileName)
+	if _, err := os.Stat(path); err != nil {
+		shell.Log(err)
+		return nil, "", false
+	}
+	return r, RelPath(path), true
+}
+
+// 笔记移动后重写其他笔记中指向它的链接, before 为移动前的解析器, 返回被修改的笔记
+func rewriteLinks(before *link.Resolver, from, to string) []string {
+	after, err := link.NewResolver(StorePath)
+	if err != nil {
+		shell.Log(err)
+		return nil
+	}
+	moved := func(rel string) (string, bool) {
+		switch {
+		case rel == from:
+			return to, true
+		case strings.HasPrefix(rel, from+"/"):
+			return to + strings.TrimPrefix(rel, from), true
+		}
+		return "", false
+	}
+
+	var changed, skipped []string
+	for _, src := range after.Files() {
+		path := StorePath + src
+		data, err := readLinkSource(src)
+		if errors.Is(err, errEncryptedNote) {
+			skipped = append(skipped, src)
+			continue
+		}
+		if err != nil || isBinary(data) {
+			continue
+		}
+		out, ok := link.Rewrite(data, func(l link.Link) (string, bool) {
+			old, ok := before.Resolve(l.Target)
+			if !ok {
+				return "", false
+			}
+			target, ok := moved(old)
+			if !ok {
+				return "", false
+			}
+			// 按标题/文件名/ID 仍能找到的链接保持原样
+			if now, ok := after.Resolve(l.Target); ok && now == target {
+				return "", false
+			}
+			// 保留 #标题 锚点, [[目标|显示文字]] 中的显示文字不在替换范围内
+			name, fragment := l.Target, ""
+			if i := strings.Index(name, "#"); i > 0 {
+				name, fragment = name[:i], name[i:]
+			}
+			if filepath.Ext(name) == "" {
+				target = strings.TrimSuffix(target, filepath.Ext(target))
+			}
+			return target + fragment, true
+		})
+		if !ok {
+			continue
+		}
+		// 加密笔记重新加密后写回, 明文笔记保持明文
+		if crypt.IsEncryptedFile(path) {
+			if out, err = encodeNote(path, out); err != nil {
+				shell.Log(err)
+				continue
+			}
+		}
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			shell.Log(err)
+			continue
+		}
+		changed = append(changed, src)
+	}
+	printSkipped("其中的链接没有更新", skipped)
+	return changed
+}
+
+// 读取笔记内容用于解析链接, 加密笔记解密后返回, 无法解密时返回 errEncryptedNote
+func readLinkSource(rel string) ([]byte, error) {
+	data, err := os.ReadFile(StorePath + rel)
+	if err != nil || !crypt.IsEncrypted(data) {
+		return data, err
+	}
+	plain, err := decodeNote(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errEncryptedNote, rel, err)
+	}
+	return plain, nil
+}
+
+// 提示无法解密的加密笔记
+func printSkipped(reason string, skipped []string) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Printf("%s以下加密笔记无法解密, %s:%s\n", shell.BrightRed, reason, shell.ResetAll)
+	for _, src := range skipped {
+		fmt.Printf(" - %s\n", src)
+	}
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -0,0 +1,60 @@
+package lib
+
+import (
+	"bytes"
+	"note/client/crypt"
+	"os"
+	"testing"
+)
+
+// 移动笔记时加密笔记中的链接解密后更新, 再重新加密写回
+func TestMoveRewritesEncryptedLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string // 输入的密码, 加密笔记使用 pw
+		rewrite bool
+	}{
+		{name: "密码正确", env: "pw", rewrite: true},
+		{name: "无法解密", env: "wrong", rewrite: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			setPassphrase(t, tt.env)
+			writeNote(t, "a.md", "# A\n", "新建")
+			if err := os.WriteFile(StorePath+"plain.md", []byte("见 [[a]]\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			writeEncrypted(t, "secret.md", "见 [[a#A|A]]\n", "pw")
+			before, err := os.ReadFile(StorePath + "secret.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := MoveNote("a.md", "dir/b.md"); err != nil {
+				t.Fatal(err)
+			}
+
+			if data, _ := os.ReadFile(StorePath + "plain.md"); string(data) != "见 [[dir/b]]\n" {
+				t.Errorf("plain.md = %q", data)
+			}
+			data, err := os.ReadFile(StorePath + "secret.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.rewrite {
+				if !bytes.Equal(data, before) {
+					t.Error("secret.md changed without the passphrase")
+				}
+				return
+			}
+			plain, err := crypt.Decrypt(data, "pw")
+			if err != nil {
+				t.Fatalf("secret.md is not encrypted with the passphrase: %v", err)
+			}
+			if string(plain) != "见 [[dir/b#A|A]]\n" {
+				t.Errorf("secret.md = %q", plain)
+			}
+		})
+	}
+}
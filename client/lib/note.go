@@ -1,10 +1,14 @@
 package lib
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/alecthomas/chroma/quick"
 	"note/cfg"
 	"note/client/git"
+	"note/client/link"
+	"note/client/meta"
+	"note/client/trash"
 	"note/shell"
 	"os"
 	"os/exec"
@@ -20,11 +24,14 @@ var (
 	RemoteURL = ""
 )
 
-func init() {
+// 配置加载后调用
+func Init() {
 	// 初始化配置
 	StorePath = cfg.DefaultCfg.App.Db + "/"
 	Editor = cfg.DefaultCfg.App.Editor
 	RemoteURL = cfg.DefaultCfg.Git.RemoteURL
+	// 同步时合并分叉的加密笔记需要密码
+	git.Passphrase = passphrase
 }
 
 func Help() {
@@ -32,28 +39,98 @@ func Help() {
 }
 
 func MoveFile(filePath, targetPath string) {
-	// 移动文件
-	err := os.Rename(StorePath+filePath, StorePath+targetPath)
+	err := MoveNote(filePath, targetPath)
 	if err != nil {
 		fmt.Println("移动文件失败:", err)
 		return
 	}
-	CommitGit("移动文件: from " + filePath + " to " + targetPath)
 	fmt.Println("文件移动成功！")
 }
 
+// 移动文件并提交, 不做任何输出
+
+func MoveNote(filePath, targetPath string) error {
+	return MoveNoteRel(RelPath(ResolvePath(filePath)), targetPath)
+}
+
+// 按相对路径移动, 不解析下标和 ID, 名为 3 或 1.md 的笔记也不会被当成下标
+func MoveNoteRel(filePath, targetPath string) error {
+	filePath = filepath.ToSlash(filepath.Clean(filePath))
+	targetPath = filepath.ToSlash(filepath.Clean(targetPath))
+	before, err := link.NewResolver(StorePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(StorePath+targetPath), 0755); err != nil {
+		return err
+	}
+	err = os.Rename(StorePath+filePath, StorePath+targetPath)
+	if err != nil {
+		return err
+	}
+	removeIndex(filePath)
+	moveID(filePath, targetPath)
+	// 指向被移动笔记的链接一起改掉, 和移动放在同一个提交里
+	rewritten := rewriteLinks(before, filePath, targetPath)
+	updateIndex(append(rewritten, targetPath)...)
+	return Commit("移动文件: from " + filePath + " to " + targetPath)
+}
+
 func Edit(fileName string) {
-	// 如果fileName 格式为1 或者 1.1
+	editPath(ResolvePath(fileName))
+}
+
+// 用编辑器打开存储目录下的完整路径, 修改后提交
+func editPath(path string) {
+	isModify := createNote(path, nil)
+	if isModify {
+		CommitGit(filepath.Base(path))
+	}
+}
+
+// 直接写入笔记内容并提交, 供 mcp 等无法打开编辑器的场景使用
+
+func WriteNote(fileName, content string) error {
+	path := ResolvePath(fileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := encodeNote(path, []byte(content))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	updateIndex(RelPath(path))
+	assignID(RelPath(path))
+	return Commit(filepath.Base(path))
+}
+
+// 笔记是否存在, fileName 支持下标, 笔记 ID 和相对路径
+
+func NoteExists(fileName string) bool {
+	path := ResolvePath(fileName)
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// 将下标(1 或者 1.1), 笔记 ID(@k3x9) 或相对路径解析为存储目录下的完整路径
+
+func ResolvePath(fileName string) string {
 	if isIndexString(fileName) {
 		Map := shell.GetKeyMap(StorePath)
-		fileName = Map[fileName]
-	} else {
-		fileName = StorePath + fileName
+		return Map[fileName]
 	}
-	isModify := createNote(fileName)
-	if isModify {
-		CommitGit(filepath.Base(fileName))
+	if id, ok := strings.CutPrefix(fileName, "@"); ok {
+		if rel, ok := lookupID(id); ok {
+			return StorePath + rel
+		}
 	}
+	return StorePath + fileName
 }
 
 func isIndexString(fileName string) bool {
@@ -79,7 +156,23 @@ func isIndexString(fileName string) bool {
 	return true
 }
 
-func createNote(path string) bool {
+// 用编辑器打开笔记, 新建时预先写入 skeleton, 为空时使用 front matter
+func createNote(path string, skeleton []byte) bool {
+	var isModify bool
+	if shouldEncrypt(path) {
+		isModify = editEncrypted(path, skeleton)
+	} else {
+		isModify = editFile(path, skeleton)
+	}
+	if isModify {
+		updateIndex(RelPath(path))
+		assignID(RelPath(path))
+	}
+	return isModify
+}
+
+// 用编辑器打开文件, 返回文件是否被修改
+func editFile(path string, skeleton []byte) bool {
 	// 获取原始文件状态
 	var originalExists bool
 	var originalModTime time.Time
@@ -90,6 +183,19 @@ func createNote(path string) bool {
 		originalExists = false
 	}
 
+	// 新建的文本笔记预先写入 front matter 或模板, 如果没有改动则视为未创建
+	if originalExists {
+		skeleton = nil
+	} else if skeleton == nil && meta.Supports(path) {
+		skeleton, _ = meta.Render(meta.New(path), nil)
+	}
+	if skeleton != nil {
+		if err := os.WriteFile(path, skeleton, 0644); err != nil {
+			shell.Log(err)
+			skeleton = nil
+		}
+	}
+
 	// 创建命令：vim 编辑文件
 	cmd := exec.Command(Editor, path) // 假设 Editor 是已定义的编辑器路径变量
 
@@ -103,6 +209,12 @@ func createNote(path string) bool {
 		panic(err)
 	}
 
+	if skeleton != nil {
+		if content, err := os.ReadFile(path); err == nil && bytes.Equal(content, skeleton) {
+			os.Remove(path)
+		}
+	}
+
 	// 获取编辑后的文件状态
 	var newExists bool
 	var newModTime time.Time
@@ -114,14 +226,22 @@ func createNote(path string) bool {
 	}
 
 	// 判断文件是否被修改
+	var isModify bool
 	switch {
 	case originalExists != newExists: // 存在状态变化
-		return true
+		isModify = true
 	case !originalExists && !newExists: // 文件从未存在
-		return false
+		isModify = false
 	default: // 比较修改时间
-		return !originalModTime.Equal(newModTime)
+		isModify = !originalModTime.Equal(newModTime)
+	}
+	if isModify && originalExists && newExists {
+		// 刷新 front matter 中的 updated 字段
+		if err := meta.Touch(path); err != nil {
+			shell.Log(err)
+		}
 	}
+	return isModify
 }
 
 func CreateDir(dirName string) {
@@ -133,49 +253,31 @@ func CreateDir(dirName string) {
 	}
 }
 
-func ViewNote(fileName string) {
-	var path string
-	if isIndexString(fileName) {
-		Map := shell.GetKeyMap(StorePath)
-		path = Map[fileName]
-	} else {
-		path = StorePath + fileName
-	}
-	//file, err := os.Open(path)
-	bytes, err := os.ReadFile(path)
+// 读取笔记内容, fileName 支持下标和相对路径, 加密的笔记自动解密
+
+func ReadNote(fileName string) ([]byte, error) {
+	data, err := os.ReadFile(ResolvePath(fileName))
 	if err != nil {
-		shell.Log(err)
-		return
-	}
-	ext := strings.TrimPrefix(filepath.Ext(path), ".")
-	if ext == "" {
-		ext = "go"
+		return nil, err
 	}
-	// 根据文件扩展名设置语法高亮
-	quick.Highlight(os.Stdout, string(bytes), ext, "terminal256", "monokai")
-
+	return decodeNote(data)
 }
 
 // 搜索本目录所有匹配的文件
 func Search(keyWord string) {
-	output := Exec("grep", "--color", "-irn", keyWord, StorePath)
-
-	ret := strings.Split(output, "\n")
-	//fmt.Println(output)
-
-	Map := shell.GetValMap(StorePath)
-	//fmt.Println(Map)
-
-	for _, subString := range ret {
-		s := ExtractPath(subString)
-		s = strings.Replace(s, "./", "", 1)
-		first := strings.Index(subString, ":")
-		//left := subString[:first]
-		_ = subString[first+1:]
-		//match := strings.Split(subString, ":")
-		fmt.Println(Map[s], subString)
+	for _, line := range SearchLines(keyWord) {
+		fmt.Println(line)
 	}
+}
 
+// 搜索结果, 每行前面带上目录树下标
+func SearchLines(keyWord string) []string {
+	results, err := searchNotes(keyWord)
+	if err != nil {
+		shell.Log(err)
+		return nil
+	}
+	return formatResults(results)
 }
 
 // =================== 云仓库存储 ==================
@@ -190,16 +292,21 @@ func InitGit() {
 }
 
 func CommitGit(title string) {
-	g, err := git.NewClient(StorePath, RemoteURL, "")
+	err := Commit(title)
 	if err != nil {
 		shell.Log(err)
 		return
 	}
-	err = g.CommitChanges(title)
+}
+
+// 提交变更, 错误交给调用方处理
+
+func Commit(title string) error {
+	g, err := git.NewClient(StorePath, RemoteURL, "")
 	if err != nil {
-		shell.Log(err)
-		return
+		return err
 	}
+	return g.CommitChanges(title)
 }
 func PullGit() {
 	g, err := git.NewClient(StorePath, RemoteURL, "")
@@ -217,17 +324,24 @@ func SyncGit() {
 		return
 	}
 	conflicts, err := g.Sync()
-	if err != nil {
+	if err != nil && len(conflicts) > 0 {
+		fmt.Println("发现冲突文件, 无冲突的部分已自动合并:")
+		for _, f := range conflicts {
+			fmt.Printf(" - %s\n", f)
+		}
+		for _, f := range conflicts {
+			g.HandleConflictResolution(f)
+		}
+		// 冲突处理完后提交合并并推送
+		conflicts, err = g.Sync()
 		if len(conflicts) > 0 {
-			fmt.Println("发现冲突文件:")
-			for _, f := range conflicts {
-				fmt.Printf(" - %s\n", f)
-			}
-			g.HandleConflictResolution(conflicts[0])
-		} else {
-			fmt.Println("sync fail:", err)
+			fmt.Println("仍有冲突未解决, 处理后重新执行 note push")
+			return
 		}
 	}
+	if err != nil {
+		fmt.Println("sync fail:", err)
+	}
 }
 
 func ShowLog() {
@@ -239,19 +353,96 @@ func ShowLog() {
 	g.ShowLog()
 }
 
-func RemoveFile(fileName string) {
-	if isIndexString(fileName) {
-		Map := shell.GetKeyMap(StorePath)
-		fileName = Map[fileName]
-	} else {
-		fileName = StorePath + fileName
+func RemoveFile(fileName string, yes bool) {
+	path := ResolvePath(fileName)
+	if path == "" {
+		shell.Log(fmt.Errorf("没有找到笔记: %s", fileName))
+		return
+	}
+	if err := checkRemovable(RelPath(path)); err != nil {
+		shell.Log(err)
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() && !yes {
+		n := countFiles(path)
+		if !confirm(fmt.Sprintf("确定删除目录 %s (包含 %d 个文件)?", RelPath(path), n)) {
+			return
+		}
+	}
+	item, err := DeleteNote(fileName)
+	if err != nil {
+		shell.Log(err)
+		return
 	}
+	fmt.Printf("已移到回收站, 恢复执行 note trash restore %s\n", item.ID)
+}
+
+// 移到回收站并提交, 不做任何输出
+
+func DeleteNote(fileName string) (*trash.Item, error) {
+	path := ResolvePath(fileName)
+	if path == "" {
+		return nil, fmt.Errorf("没有找到笔记: %s", fileName)
+	}
+	return DeleteNoteRel(RelPath(path))
+}
+
+// 按相对路径移到回收站, 不解析下标和 ID
+func DeleteNoteRel(rel string) (*trash.Item, error) {
+	if err := checkRemovable(rel); err != nil {
+		return nil, err
+	}
+	return trashNote(rel)
+}
 
-	err := os.RemoveAll(fileName)
+// 列出远程仓库, 主仓库用 * 标出
+func ShowRemotes() {
+	g, err := git.NewClient(StorePath, RemoteURL, "")
 	if err != nil {
 		shell.Log(err)
 		return
 	}
-	CommitGit("删除文件:" + fileName)
-	fmt.Println("文件删除成功！")
+	remotes, err := g.Remotes()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	if len(remotes) == 0 {
+		fmt.Println("没有远程仓库, 执行 note remote add name url 添加")
+		return
+	}
+	for _, r := range remotes {
+		mark, source := " ", ""
+		if r.Primary {
+			mark = "*"
+		}
+		if r.Configured {
+			source = " (配置文件)"
+		}
+		fmt.Printf("%s %s%-8s%s %s%s\n", mark, shell.BrightYellow, r.Name, shell.ResetAll, r.URL, source)
+	}
+}
+
+func AddRemote(name, url string) error {
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	if err := g.AddRemote(name, url); err != nil {
+		return err
+	}
+	fmt.Printf("已添加远程仓库 %s, note push 时会同时推送\n", name)
+	return nil
+}
+
+func RemoveRemote(name string) error {
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	if err := g.RemoveRemote(name); err != nil {
+		return err
+	}
+	fmt.Printf("已删除远程仓库 %s\n", name)
+	return nil
 }
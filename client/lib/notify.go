@@ -1,124 +1,86 @@
 package lib
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"note/cfg"
+	"note/client/notify"
 	"note/shell"
 	"os"
-	"os/exec"
-	"regexp"
-	"runtime"
-	"strconv"
 	"time"
 )
 
-var StartTime time.Time
-
-func Start() {
-	StartTime = time.Now()
-	Loop()
-}
-
-func Loop() {
-	var lastModTime time.Time // 记录文件最后修改时间
-	// 1. 打开todolist文件
-	file, err := os.OpenFile("./db/todolist", os.O_RDONLY, 0666)
-	defer file.Close()
+// 每秒检查一次到期的提醒, todolist 修改后自动重新读取,
+// 已提醒的状态记录在 .note/remind.yaml, 重启后不会重复提醒, ctx 取消后保存状态并返回
+func Loop(ctx context.Context) {
+	s, err := newScheduler()
 	if err != nil {
 		shell.Log(err)
 		return
 	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	for {
-		// 2. 检查文件是否更新
-		fileInfo, _ := file.Stat()
-		if fileInfo.ModTime().Before(lastModTime) {
-			time.Sleep(time.Second)
-			continue
+		select {
+		case <-ctx.Done():
+			if err := s.Save(); err != nil {
+				shell.Log(err)
+			}
+			return
+		case <-ticker.C:
 		}
-		file.Close()
-		file, err = os.OpenFile("./db/todolist", os.O_RDONLY, 0666)
-		if err != nil {
+		if err := s.Reload(); err != nil {
 			shell.Log(err)
-			return
+			continue
 		}
-		lastModTime = fileInfo.ModTime()
-
-		// 3. 重置文件指针到开头
-		file.Seek(0, 0)
-
-		// 4. 按行读取新内容
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := scanner.Text()
-			t, err := parseTimeFromString(line)
-			if err != nil {
-				//log.Log(err)
-				continue
-			}
-			if t.Truncate(time.Second) == time.Now().Truncate(time.Second) {
-				go Notify(line, " 提醒！！！")
+		now := time.Now()
+		for _, r := range s.Due(now) {
+			msg := "提醒！！！"
+			// 服务停止期间错过的提醒补上一次
+			if next := s.Next(r); now.Sub(next) > time.Minute {
+				msg = fmt.Sprintf("提醒！！！(错过 %s)", next.Format(remindLayout))
 			}
+			go Notify(r.Notify, r.Text, msg)
+			s.Fired(r, now)
+		}
+		if err := s.Save(); err != nil {
+			shell.Log(err)
 		}
-		time.Sleep(time.Second)
-	}
-}
-
-func Notify(title, msg string) {
-	DoNotify(title, msg)
-	time.Sleep(time.Second)
-	DoNotify(title, msg)
-	time.Sleep(time.Second)
-	DoNotify(title, msg)
-}
-
-func DoNotify(title, message string) {
-	if runtime.GOOS == "darwin" {
-		exec.Command("afplay", "/System/Library/Sounds/Ping.aiff").Start()
-	} else {
-		fmt.Print("\a")
 	}
-	// todo 后台运行时暂未能处理输出
-	// 直接写入标准错误（通常无缓冲）
-	//fmt.Fprintf(os.Stderr, "%s\n%s\n", title, message)
-
-	// 或手动刷新标准输出
-	//fmt.Printf("%s\n%s\n", title, message)
-	//if f, ok := os.Stdout.(*os.File); ok {
-	//	f.Sync() // 强制刷新缓冲区
-	//}
-	//os.Stdout.WriteString(title + "\n")
-	//os.Stdout.WriteString(message + "\n")
-	fmt.Printf("\u001B[33m %s (%s) \u001b[0m\n", title, message)
 }
 
-// 解析时间字符串，返回对应的时间戳
-func parseTimeFromString(s string) (time.Time, error) {
-	// 匹配两种时间格式：HH:MM 和 Xmin
-	hhmmRegex := regexp.MustCompile(`(\d{1,2}):(\d{2})`)
-	minRegex := regexp.MustCompile(`(\d+)min`)
+// 提醒日志文件的默认位置, 不提交到仓库
+const remindLog = ".note/remind.log"
 
-	// 先尝试匹配 HH:MM 格式
-	if hhmmMatch := hhmmRegex.FindStringSubmatch(s); len(hhmmMatch) == 3 {
-		hour, _ := strconv.Atoi(hhmmMatch[1])
-		minute, _ := strconv.Atoi(hhmmMatch[2])
+// 创建通知方式, 测试时替换
+var newNotifier = notify.New
 
-		// 验证时间有效性
-		if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
-			return time.Time{}, fmt.Errorf("invalid time: %02d:%02d", hour, minute)
+// 按提醒单独指定或配置文件中的通知方式发送提醒
+func Notify(backends []string, title, msg string) {
+	if len(backends) == 0 {
+		backends = cfg.DefaultCfg.Notify.Backends
+	}
+	if len(backends) == 0 {
+		backends = []string{notify.NameStdout, notify.NameFile}
+		if notify.DesktopAvailable() {
+			backends = append(backends, notify.NameDesktop)
 		}
-
-		// 构造当天时间
-		now := time.Now()
-		target := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.Local)
-
-		return target, nil
 	}
-
-	// 尝试匹配 Xmin 格式
-	if minMatch := minRegex.FindStringSubmatch(s); len(minMatch) == 2 {
-		minutes, _ := strconv.Atoi(minMatch[1])
-		return StartTime.Add(time.Duration(minutes) * time.Minute), nil
+	opt := notify.Options{
+		Webhook: cfg.DefaultCfg.Notify.Webhook,
+		File:    cfg.DefaultCfg.Notify.File,
+	}
+	if opt.File == "" {
+		opt.File = StorePath + remindLog
+	}
+	n, err := newNotifier(backends, opt)
+	if err != nil {
+		shell.Log(err)
+		// 配置有误时至少输出到终端和日志, 不丢失提醒
+		n = notify.Multi{&notify.Stdout{W: os.Stdout}, &notify.File{Path: opt.File}}
+	}
+	err = n.Notify(notify.Message{Title: title, Body: msg, Time: time.Now()})
+	if err != nil {
+		shell.Log(err)
 	}
-
-	return time.Time{}, fmt.Errorf("no valid time pattern found")
 }
@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"errors"
+	"note/cfg"
+	"note/client/notify"
+	"os"
+	"strings"
+	"testing"
+)
+
+type fakeNotifier struct {
+	msgs []notify.Message
+}
+
+func (f *fakeNotifier) Notify(m notify.Message) error {
+	f.msgs = append(f.msgs, m)
+	return nil
+}
+
+// 替换存储目录, 通知方式配置和 newNotifier, 测试结束后恢复
+func setupNotify(t *testing.T, backends []string, fn func([]string, notify.Options) (notify.Notifier, error)) {
+	oldStore, oldNotify, oldNew := StorePath, cfg.DefaultCfg.Notify, newNotifier
+	t.Cleanup(func() {
+		StorePath, cfg.DefaultCfg.Notify, newNotifier = oldStore, oldNotify, oldNew
+	})
+	StorePath = t.TempDir() + "/"
+	cfg.DefaultCfg.Notify.Backends = backends
+	cfg.DefaultCfg.Notify.Webhook = ""
+	cfg.DefaultCfg.Notify.File = ""
+	newNotifier = fn
+}
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   []string // 配置文件中的通知方式
+		backends []string // 提醒单独指定的通知方式
+		want     []string // 传给 notify.New 的通知方式, 只比较前缀, 桌面通知视环境而定
+	}{
+		{name: "默认", want: []string{notify.NameStdout, notify.NameFile}},
+		{name: "配置", config: []string{notify.NameWebhook}, want: []string{notify.NameWebhook}},
+		{name: "单独指定", config: []string{notify.NameWebhook}, backends: []string{notify.NameFile}, want: []string{notify.NameFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeNotifier{}
+			var gotNames []string
+			var gotOpt notify.Options
+			setupNotify(t, tt.config, func(names []string, opt notify.Options) (notify.Notifier, error) {
+				gotNames, gotOpt = names, opt
+				return fake, nil
+			})
+
+			Notify(tt.backends, "开会", "提醒！！！")
+
+			if len(gotNames) < len(tt.want) || strings.Join(gotNames[:len(tt.want)], ",") != strings.Join(tt.want, ",") {
+				t.Errorf("backends = %v, want %v", gotNames, tt.want)
+			}
+			if gotOpt.File != StorePath+remindLog {
+				t.Errorf("file = %q, want %q", gotOpt.File, StorePath+remindLog)
+			}
+			if len(fake.msgs) != 1 || fake.msgs[0].Title != "开会" || fake.msgs[0].Body != "提醒！！！" {
+				t.Errorf("messages = %v", fake.msgs)
+			}
+		})
+	}
+}
+
+// 通知方式配置有误时退回到终端和日志文件
+func TestNotifyFallback(t *testing.T) {
+	setupNotify(t, []string{"sms"}, func([]string, notify.Options) (notify.Notifier, error) {
+		return nil, errors.New("不支持的通知方式: sms")
+	})
+
+	Notify(nil, "开会", "提醒！！！")
+
+	data, err := os.ReadFile(StorePath + remindLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "开会 提醒！！！") {
+		t.Errorf("remind.log = %q", data)
+	}
+}
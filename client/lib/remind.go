@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"fmt"
+	"note/client/remind"
+	"note/shell"
+	"strconv"
+	"time"
+)
+
+const remindLayout = "2006-01-02 15:04"
+
+// 读取提醒, 提醒状态会写入 .note/remind.yaml, 只对本机有效, 先加入 .gitignore
+func newScheduler() (*remind.Scheduler, error) {
+	if err := ensureIgnored("/" + remind.StateFile); err != nil {
+		shell.Log(err)
+	}
+	return remind.NewScheduler(StorePath)
+}
+
+// 列出 todolist 中的提醒及下一次提醒时间
+func ShowReminders() {
+	s, err := newScheduler()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	rs := s.Reminders()
+	if len(rs) == 0 {
+		fmt.Println("没有提醒事项, 在", StorePath+remind.File, "中添加, 举例: every Mon 09:30 周会")
+		return
+	}
+	for i, r := range rs {
+		next := "已结束"
+		if t := s.Next(r); !t.IsZero() {
+			next = t.Format(remindLayout)
+		}
+		fmt.Printf("%s%d%s %s%-16s%s %s\n", shell.BrightYellow, i+1, shell.ResetAll,
+			shell.BrightGreen, next, shell.ResetAll, r.Text)
+	}
+}
+
+// 第 n 条提醒推迟一段时间后再提醒, 默认 10 分钟
+func SnoozeReminder(n, after string) error {
+	d := 10 * time.Minute
+	if after != "" {
+		var err error
+		if d, err = remind.ParseDuration(after); err != nil {
+			return err
+		}
+	}
+	s, err := newScheduler()
+	if err != nil {
+		return err
+	}
+	rs := s.Reminders()
+	i, err := strconv.Atoi(n)
+	if err != nil || i < 1 || i > len(rs) {
+		return fmt.Errorf("没有第 %s 条提醒, 执行 note remind 查看", n)
+	}
+	until := time.Now().Add(d).Truncate(time.Minute)
+	s.Snooze(rs[i-1], until)
+	if err := s.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("%s 推迟到 %s\n", rs[i-1].Text, until.Format(remindLayout))
+	return nil
+}
@@ -0,0 +1,185 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"note/cfg"
+	"note/client/git"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 提醒服务在后台定期同步: 拉取合并主仓库并推送到全部远程仓库,
+// 网络不通等失败时按 30s, 1m, 2m ... 退避重试, 最长 1 小时. 同步状态保存在 .note/sync.yaml
+
+const (
+	syncState       = ".note/sync.yaml"
+	defaultInterval = 5 * time.Minute
+	minBackoff      = 30 * time.Second
+	maxBackoff      = time.Hour
+)
+
+type syncInfo struct {
+	LastAttempt time.Time `yaml:"last_attempt"`
+	LastSuccess time.Time `yaml:"last_success,omitempty"`
+	LastResult  string    `yaml:"last_result,omitempty"`
+	LastError   string    `yaml:"last_error,omitempty"`
+	Failures    int       `yaml:"failures"`
+	NextAttempt time.Time `yaml:"next_attempt"`
+}
+
+// 后台同步间隔, 关闭或没有远程仓库时返回 0
+func syncInterval() time.Duration {
+	if RemoteURL == "" && len(cfg.DefaultCfg.Git.Remotes) == 0 {
+		return 0
+	}
+	s := cfg.DefaultCfg.Git.SyncInterval
+	switch s {
+	case "":
+		return defaultInterval
+	case "off", "0":
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		shell.Log("github.sync_interval 无效: ", s, ", 使用默认值 ", defaultInterval)
+		return defaultInterval
+	}
+	return d
+}
+
+func syncLoop(ctx context.Context) {
+	interval := syncInterval()
+	if interval == 0 {
+		return
+	}
+	info := loadSyncInfo()
+	wait := time.Until(info.NextAttempt)
+	for {
+		if wait < 0 {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		info = backgroundSync(info, interval)
+		wait = time.Until(info.NextAttempt)
+	}
+}
+
+func backgroundSync(info syncInfo, interval time.Duration) syncInfo {
+	now := time.Now()
+	info.LastAttempt = now
+	result, err := func() (string, error) {
+		g, err := git.NewClient(StorePath, RemoteURL, "")
+		if err != nil {
+			return "", err
+		}
+		return g.BackgroundSync()
+	}()
+	if result != "" {
+		fmt.Println("后台同步:", result)
+		info.LastResult = result
+	}
+	if err != nil {
+		info.Failures++
+		info.LastError = err.Error()
+		backoff := minBackoff << (info.Failures - 1)
+		if backoff > maxBackoff || backoff <= 0 {
+			backoff = maxBackoff
+		}
+		// 冲突和未提交的修改需要等用户处理, 退避重试没有意义, 按正常间隔检查
+		if errors.Is(err, git.ErrConflict) || errors.Is(err, git.ErrMerging) || errors.Is(err, git.ErrDirty) {
+			backoff = interval
+		}
+		info.NextAttempt = now.Add(backoff)
+		fmt.Printf("后台同步失败(第 %d 次), %s 后重试: %v\n", info.Failures, backoff, err)
+	} else {
+		info.Failures = 0
+		info.LastError = ""
+		info.LastSuccess = now
+		info.NextAttempt = now.Add(interval)
+	}
+	if err := saveSyncInfo(info); err != nil {
+		shell.Log(err)
+	}
+	return info
+}
+
+func loadSyncInfo() syncInfo {
+	var info syncInfo
+	data, err := os.ReadFile(StorePath + syncState)
+	if err == nil {
+		yaml.Unmarshal(data, &info)
+	}
+	return info
+}
+
+func saveSyncInfo(info syncInfo) error {
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(StorePath+syncState), 0755); err != nil {
+		return err
+	}
+	// 同步状态只对本机有效, 不提交
+	if err := ensureIgnored("/" + syncState); err != nil {
+		return err
+	}
+	return os.WriteFile(StorePath+syncState, data, 0644)
+}
+
+// 显示本地与主仓库相比领先/落后的提交数, fetch 为 true 时先抓取
+func SyncStatus(fetch bool) error {
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		return err
+	}
+	if fetch {
+		if err := g.Fetch(); err != nil {
+			return fmt.Errorf("抓取远程更新失败: %v", err)
+		}
+	}
+	st, err := g.Status()
+	if err != nil {
+		return err
+	}
+	if !st.Tracked {
+		fmt.Printf("%s 不存在, 本地 %d 个提交未推送\n", st.Remote, st.Ahead)
+	} else {
+		fmt.Printf("%s: 领先 %s%d%s, 落后 %s%d%s\n", st.Remote,
+			shell.BrightGreen, st.Ahead, shell.ResetAll, shell.BrightYellow, st.Behind, shell.ResetAll)
+	}
+	if st.Dirty {
+		fmt.Println("有未提交的修改")
+	}
+	if st.Merging {
+		fmt.Println("有未解决的冲突, 执行 note push 处理")
+	}
+
+	if syncInterval() == 0 {
+		fmt.Println("后台同步: 已关闭")
+		return nil
+	}
+	info := loadSyncInfo()
+	if info.LastAttempt.IsZero() {
+		fmt.Println("后台同步: 尚未运行, 执行 note daemon start 启动")
+		return nil
+	}
+	fmt.Println("上次同步:", info.LastAttempt.Format(remindLayout+":05"))
+	if !info.LastSuccess.IsZero() {
+		fmt.Println("上次成功:", info.LastSuccess.Format(remindLayout+":05"), info.LastResult)
+	}
+	if info.LastError != "" {
+		fmt.Printf("%s连续失败 %d 次: %s%s\n", shell.BrightRed, info.Failures, info.LastError, shell.ResetAll)
+	}
+	fmt.Println("下次同步:", info.NextAttempt.Format(remindLayout+":05"))
+	return nil
+}
@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"fmt"
+	"note/client/meta"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 列出所有标签及对应的笔记数量
+func ListTags() {
+	tags := collectTags()
+	for _, t := range TagNames() {
+		fmt.Printf("%s%s%s (%d)\n", shell.BrightYellow, t, shell.ResetAll, len(tags[t]))
+	}
+}
+
+// 所有标签名, 用于命令补全
+func TagNames() []string {
+	tags := collectTags()
+	names := make([]string, 0, len(tags))
+	for t := range tags {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 以目录树形式列出带有该标签的笔记
+func ShowTag(tag string) {
+	if tag == "" {
+		ListTags()
+		return
+	}
+	List(tag)
+}
+
+// 只保留带有该标签的笔记
+func tagFilter(tag string) func(path string) bool {
+	if tag == "" {
+		return nil
+	}
+	return func(path string) bool {
+		m, err := meta.ParseFile(path)
+		return err == nil && m.HasTag(tag)
+	}
+}
+
+// 收集 标签 -> 笔记路径, 标签统一转为小写
+func collectTags() map[string][]string {
+	tags := make(map[string][]string)
+	filepath.Walk(StorePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != StorePath {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !meta.Supports(path) {
+			return nil
+		}
+		m, err := meta.ParseFile(path)
+		if err != nil || m == nil {
+			return nil
+		}
+		for _, t := range m.Tags {
+			t = strings.ToLower(t)
+			tags[t] = append(tags[t], path)
+		}
+		return nil
+	})
+	return tags
+}
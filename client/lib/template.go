@@ -0,0 +1,168 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"note/cfg"
+	"note/client/git"
+	"note/client/meta"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 笔记模板放在存储目录的 templates 下, 如 templates/meeting.md, 使用 Go text/template 语法,
+// 可用变量见 templateData, {{prompt "参会人"}} 或 {{prompt "地点" "会议室"}} 会在创建时提示输入
+
+const templateDir = "templates"
+
+type templateData struct {
+	Date    string // 2026-10-17
+	Time    string // 15:04
+	Weekday string // 星期六
+	Title   string // 默认为文件名去掉扩展名
+	Author  string // 配置中的 github.user, 未配置时为当前系统用户
+	Branch  string // 存储仓库的当前分支
+	Path    string // 笔记相对存储目录的路径
+}
+
+func newTemplateData(path, title string, now time.Time) templateData {
+	if title == "" {
+		name := filepath.Base(path)
+		title = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	author := cfg.DefaultCfg.Git.User
+	if author == "" {
+		author = os.Getenv("USER")
+	}
+	branch, _ := git.CurrentBranch(StorePath)
+	return templateData{
+		Date:    now.Format(dateLayout),
+		Time:    now.Format("15:04"),
+		Weekday: weekdays[now.Weekday()],
+		Title:   title,
+		Author:  author,
+		Branch:  branch,
+		Path:    RelPath(path),
+	}
+}
+
+// 按名称查找模板, 没有扩展名时依次尝试同名文件, .md 和 .txt
+func findTemplate(name string) (string, error) {
+	base := StorePath + templateDir + "/" + name
+	for _, path := range []string{base, base + ".md", base + ".txt"} {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("模板 %s 不存在, 执行 note templates 查看可用模板", name)
+}
+
+func renderTemplate(file string, data templateData) ([]byte, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	answers := make(map[string]string)
+	in := bufio.NewReader(os.Stdin)
+	funcs := template.FuncMap{
+		// 同一个字段只询问一次, 没有输入时使用默认值
+		"prompt": func(label string, def ...string) string {
+			if v, ok := answers[label]; ok {
+				return v
+			}
+			hint := ""
+			if len(def) > 0 {
+				hint = " [" + def[0] + "]"
+			}
+			fmt.Printf("%s%s: ", label, hint)
+			line, _ := in.ReadString('\n')
+			v := strings.TrimSpace(line)
+			if v == "" && len(def) > 0 {
+				v = def[0]
+			}
+			answers[label] = v
+			return v
+		},
+	}
+	name := filepath.Base(file)
+	tmpl, err := template.New(name).Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return withFrontMatter(data.Path, data.Title, buf.Bytes()), nil
+}
+
+// 模板中没有 front matter 时补上, 和普通新建笔记保持一致
+func withFrontMatter(path, title string, content []byte) []byte {
+	if !meta.Supports(path) {
+		return content
+	}
+	if m, _, err := meta.Parse(content); err != nil || m != nil {
+		return content
+	}
+	m := meta.New(path)
+	m.Title = title
+	out, err := meta.Render(m, append([]byte("\n"), content...))
+	if err != nil {
+		return content
+	}
+	return out
+}
+
+// 用模板新建笔记, 笔记已存在时直接打开
+func EditWithTemplate(fileName, name string) error {
+	path := ResolvePath(fileName)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("%s 已存在, 忽略模板 %s\n", RelPath(path), name)
+		Edit(fileName)
+		return nil
+	}
+	file, err := findTemplate(name)
+	if err != nil {
+		return err
+	}
+	skeleton, err := renderTemplate(file, newTemplateData(path, "", time.Now()))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if createNote(path, skeleton) {
+		CommitGit(filepath.Base(path))
+	}
+	return nil
+}
+
+// 列出 templates 下的模板
+func ListTemplates() {
+	names := TemplateNames()
+	if len(names) == 0 {
+		fmt.Printf("还没有模板, 在 %s 下新建, 如 note add %s/meeting.md\n", StorePath+templateDir, templateDir)
+		return
+	}
+	for _, name := range names {
+		fmt.Printf("%s%s%s\n", shell.BrightYellow, name, shell.ResetAll)
+	}
+}
+
+// 模板名, 用于命令补全
+func TemplateNames() []string {
+	entries, _ := os.ReadDir(StorePath + templateDir)
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+		}
+	}
+	return names
+}
@@ -20,13 +20,17 @@ func Exec(cmdName string, Args ...string) string {
 
 	// 实时捕获输出（含颜色）
 	var coloredOutput bytes.Buffer
+	copied := make(chan struct{})
 	go func() {
+		defer close(copied)
 		//io.Copy(io.MultiWriter(os.Stdout, &coloredOutput), ptmx) // 同时输出到终端和缓冲区
 		io.Copy(io.MultiWriter(&coloredOutput), ptmx) // 只输出到缓冲区
 	}()
 
 	// 等待命令结束
 	err = cmd.Wait()
+	// 等待输出读取完毕, 否则可能拿到不完整的结果
+	<-copied
 	if err != nil {
 		if err.Error() == "exit status 1" {
 			return ""
@@ -0,0 +1,234 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"note/client/remind"
+	"note/shell"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 待办保存在 todolist 中, 每行一条 - [ ] 内容 提醒时间, 由提醒服务读取,
+// 完成的待办移到 todolist.done 中归档
+
+const todoArchive = "todolist.done"
+
+type todoItem struct {
+	line int // 在文件中的行下标, 从 0 开始
+	text string
+}
+
+func readTodos() ([]string, []todoItem, error) {
+	data, err := os.ReadFile(StorePath + remind.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// 空文件没有任何行, 避免添加时多出一个空行
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, nil, nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	var items []todoItem
+	for i, line := range lines {
+		text, done := remind.TrimCheckbox(line)
+		if text == "" || strings.HasPrefix(text, "#") || done {
+			continue
+		}
+		items = append(items, todoItem{line: i, text: text})
+	}
+	return lines, items, nil
+}
+
+func writeTodos(lines []string) error {
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return os.WriteFile(StorePath+remind.File, []byte(content), 0644)
+}
+
+// 添加待办, at / in / every 至多指定一个, 都为空时不提醒
+func AddTodo(text, at, in, every string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return errors.New("待办内容不能为空")
+	}
+	set := 0
+	for _, v := range []string{at, in, every} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("--at, --in, --every 只能指定一个")
+	}
+	when, err := todoWhen(at, in, every, time.Now())
+	if err != nil {
+		return err
+	}
+	line := "- [ ] " + text
+	if when != "" {
+		line += " " + when
+	}
+	lines, _, err := readTodos()
+	if err != nil {
+		return err
+	}
+	if err := writeTodos(append(lines, line)); err != nil {
+		return err
+	}
+	CommitGit("添加待办: " + text)
+	fmt.Println("已添加:", line)
+	return nil
+}
+
+// 一次性的提醒写成绝对时间, 服务重启或第二天再看都不会有歧义
+func todoWhen(at, in, every string, now time.Time) (string, error) {
+	switch {
+	case at != "":
+		spec, err := remind.ParseSpec(at)
+		if err != nil {
+			return "", fmt.Errorf("无法识别的时间 %s, 举例 15:00 / Mon 09:30 / 2026-10-20 15:00", at)
+		}
+		if spec.Kind != remind.Once && spec.Kind != remind.Clock {
+			return "", fmt.Errorf("%s 不是一个时间点, 重复提醒请使用 --every, 时长请使用 --in", at)
+		}
+		t := spec.Next(now, now)
+		if t.IsZero() {
+			return "", fmt.Errorf("%s 已经过去了", at)
+		}
+		return t.Format(remindLayout), nil
+	case in != "":
+		d, err := remind.ParseDuration(in)
+		if err != nil {
+			return "", err
+		}
+		return now.Add(d).Format(remindLayout), nil
+	case every != "":
+		expr := "every " + strings.TrimPrefix(strings.TrimSpace(every), "every ")
+		spec, err := remind.ParseSpec(expr)
+		if err != nil || !spec.Recurring() {
+			return "", fmt.Errorf("无法识别的重复时间 %s, 举例 \"day 08:00\" / \"Mon,Wed 09:30\" / \"workday 09:00\" / 30min", every)
+		}
+		return spec.Expr, nil
+	}
+	return "", nil
+}
+
+// 列出未完成的待办及下一次提醒时间, done 为 true 时列出已归档的待办
+func ListTodos(done bool) {
+	if done {
+		data, err := readArchive()
+		if err != nil {
+			shell.Log(err)
+			return
+		}
+		fmt.Print(string(data))
+		return
+	}
+	_, items, err := readTodos()
+	if err != nil {
+		shell.Log(err)
+		return
+	}
+	if len(items) == 0 {
+		fmt.Println("没有待办, 执行 note todo add \"内容\" --at 15:00 添加")
+		return
+	}
+	next := make(map[int]time.Time)
+	if s, err := newScheduler(); err == nil {
+		for _, r := range s.Reminders() {
+			next[r.Line-1] = s.Next(r)
+		}
+	}
+	for i, item := range items {
+		fmt.Printf("%s%d%s %s", shell.BrightYellow, i+1, shell.ResetAll, item.text)
+		if t, ok := next[item.line]; ok && !t.IsZero() {
+			fmt.Printf(" %s(下次提醒 %s)%s", shell.BrightGreen, t.Format(remindLayout), shell.ResetAll)
+		}
+		fmt.Println()
+	}
+}
+
+// 未完成的待办, 用于命令补全
+func TodoNames() []string {
+	_, items, _ := readTodos()
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.text)
+	}
+	return names
+}
+
+// 完成第 n 条待办, 移到 todolist.done 归档
+func DoneTodo(n string) error {
+	// 归档和其它笔记一样可能被加密, 先解密, 拿不到密码时不改动 todolist
+	path := StorePath + todoArchive
+	archive, err := readArchive()
+	if err != nil {
+		return err
+	}
+	if shouldEncrypt(path) {
+		if _, err := passphrase(); err != nil {
+			return err
+		}
+	}
+	item, err := removeTodo(n)
+	if err != nil {
+		return err
+	}
+	archive = append(archive, fmt.Sprintf("- [x] %s (完成于 %s)\n", item.text, time.Now().Format(remindLayout))...)
+	data, err := encodeNote(path, archive)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	CommitGit("完成待办: " + item.text)
+	fmt.Println("已完成:", item.text)
+	return nil
+}
+
+// 读取已完成待办的归档, 加密时解密
+func readArchive() ([]byte, error) {
+	data, err := os.ReadFile(StorePath + todoArchive)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeNote(data)
+}
+
+func RemoveTodo(n string) error {
+	item, err := removeTodo(n)
+	if err != nil {
+		return err
+	}
+	CommitGit("删除待办: " + item.text)
+	fmt.Println("已删除:", item.text)
+	return nil
+}
+
+// 从 todolist 中删掉第 n 条待办
+func removeTodo(n string) (todoItem, error) {
+	lines, items, err := readTodos()
+	if err != nil {
+		return todoItem{}, err
+	}
+	i, err := strconv.Atoi(n)
+	if err != nil || i < 1 || i > len(items) {
+		return todoItem{}, fmt.Errorf("没有第 %s 条待办, 执行 note todo ls 查看", n)
+	}
+	item := items[i-1]
+	lines = append(lines[:item.line], lines[item.line+1:]...)
+	return item, writeTodos(lines)
+}
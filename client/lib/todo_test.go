@@ -0,0 +1,44 @@
+package lib
+
+import (
+	"note/client/remind"
+	"os"
+	"testing"
+)
+
+func TestAddTodo(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *string // 为 nil 时没有 todolist 文件
+		want     string
+	}{
+		{name: "没有文件", want: "- [ ] 开会\n"},
+		{name: "空文件", existing: ptr(""), want: "- [ ] 开会\n"},
+		{name: "只有空白", existing: ptr("\n  \n"), want: "- [ ] 开会\n"},
+		{name: "追加", existing: ptr("- [ ] 喝水\n"), want: "- [ ] 喝水\n- [ ] 开会\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			if tt.existing != nil {
+				if err := os.WriteFile(StorePath+remind.File, []byte(*tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := AddTodo("开会", "", "", ""); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(StorePath + remind.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("todolist = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,192 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"note/client/meta"
+	"note/client/remind"
+	"note/client/trash"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 存储目录本身和内部目录不允许删除, 防止输错下标或路径时误删
+func checkRemovable(rel string) error {
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return fmt.Errorf("不能删除存储目录: %s", rel)
+	}
+	for _, dir := range []string{".git", ".note", trash.Dir} {
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return fmt.Errorf("不能删除内部文件: %s", rel)
+		}
+	}
+	if _, err := os.Stat(StorePath + rel); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 移到回收站, 笔记 ID 记录在回收站中, 恢复时沿用
+func trashNote(rel string) (*trash.Item, error) {
+	t, err := trash.Open(StorePath)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := meta.LoadIDs(StorePath)
+	if err != nil {
+		return nil, err
+	}
+	// 先移动文件, 成功后再去掉 ID, 移动失败时笔记 ID 不变
+	item, err := t.Add(rel, ids.Under(rel), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Save(); err != nil {
+		return nil, err
+	}
+	removeID(rel)
+	removeIndex(rel)
+	return item, Commit("删除文件: " + rel)
+}
+
+// 已删除的笔记在回收站中对应的项, 包括随目录一起删除的笔记, 不在回收站中时返回 nil
+func trashedItem(rel string) *trash.Item {
+	if _, err := os.Stat(StorePath + rel); err == nil {
+		return nil
+	}
+	t, err := trash.Open(StorePath)
+	if err != nil {
+		return nil
+	}
+	for _, item := range t.Items() {
+		if item.Path == rel || strings.HasPrefix(rel, item.Path+"/") {
+			return item
+		}
+	}
+	return nil
+}
+
+// 目录下的文件数, 不含隐藏文件
+func countFiles(dir string) int {
+	n := 0
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// 在终端确认, 默认为否
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func ShowTrash() error {
+	t, err := trash.Open(StorePath)
+	if err != nil {
+		return err
+	}
+	items := t.Items()
+	if len(items) == 0 {
+		fmt.Println("回收站是空的")
+		return nil
+	}
+	for _, item := range items {
+		path := item.Path
+		if item.IsDir {
+			path += "/"
+		}
+		fmt.Printf("%s%s%s %s%s%s %s\n",
+			shell.BrightYellow, item.ID, shell.ResetAll,
+			shell.BrightCyan, item.Deleted.Format("2006-01-02 15:04:05"), shell.ResetAll,
+			path,
+		)
+	}
+	return nil
+}
+
+// 回收站中的项, 供命令补全使用
+func TrashItems() []*trash.Item {
+	t, err := trash.Open(StorePath)
+	if err != nil {
+		return nil
+	}
+	return t.Items()
+}
+
+func RestoreTrash(id string) error {
+	t, err := trash.Open(StorePath)
+	if err != nil {
+		return err
+	}
+	item, err := t.Restore(id)
+	if err != nil {
+		return err
+	}
+	if err := t.Save(); err != nil {
+		return err
+	}
+	updateIDs(func(ids *meta.IDs) {
+		for rel, noteID := range item.NoteIDs {
+			ids.Set(rel, noteID)
+		}
+	})
+	assignID(item.Path)
+	updateIndex(item.Path)
+	if err := Commit("从回收站恢复: " + item.Path); err != nil {
+		return err
+	}
+	fmt.Printf("%s已恢复 %s%s\n", shell.BrightGreen, item.Path, shell.ResetAll)
+	return nil
+}
+
+// 彻底删除回收站中超过 olderThan(如 30d) 的项, olderThan 为空时清空回收站
+func EmptyTrash(olderThan string, yes bool) error {
+	t, err := trash.Open(StorePath)
+	if err != nil {
+		return err
+	}
+	before := time.Now()
+	if olderThan != "" {
+		d, err := remind.ParseDuration(olderThan)
+		if err != nil {
+			return err
+		}
+		before = before.Add(-d)
+	} else if len(t.Items()) > 0 && !yes && !confirm(fmt.Sprintf("确定清空回收站 (%d 项)?", len(t.Items()))) {
+		return nil
+	}
+	removed, err := t.Empty(before)
+	if err != nil {
+		return err
+	}
+	if err := t.Save(); err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Println("没有需要清理的项")
+		return nil
+	}
+	if err := Commit(fmt.Sprintf("清理回收站: %d 项", len(removed))); err != nil {
+		return err
+	}
+	fmt.Printf("已彻底删除 %d 项\n", len(removed))
+	return nil
+}
@@ -0,0 +1,549 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"note/client/crypt"
+	"note/client/tui"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// note ui: 左边是可折叠的目录树(下标与 note l 一致), 右边是高亮的预览,
+// / 模糊过滤, 编辑/新建/移动/删除/提交都调用 lib 中已有的操作
+
+const uiHelp = "j/k 移动  h/l 折叠/展开  / 过滤  e 编辑  a 新建  m 移动  d 删除  c 提交  J/K 滚动预览  q 退出"
+
+// 预览最多读取的字节数
+const previewLimit = 256 * 1024
+
+type uiNode struct {
+	name     string
+	rel      string
+	index    string
+	dir      bool
+	depth    int
+	parent   *uiNode
+	children []*uiNode
+}
+
+type uiRow struct {
+	node      *uiNode
+	positions []int // 过滤时匹配到的位置
+}
+
+type uiPrompt struct {
+	label   string
+	value   string
+	confirm bool // y/n 确认, 按任意键结束
+	done    func(string)
+}
+
+type noteUI struct {
+	t        *tui.Terminal
+	root     *uiNode
+	files    []*uiNode
+	expanded map[string]bool
+	rows     []uiRow
+
+	query     string
+	filtering bool // 正在输入过滤条件
+
+	cursor, offset int
+	prompt         *uiPrompt
+	message        string
+	quit           bool
+
+	previewRel  string
+	previewTime time.Time
+	preview     []string
+	previewOff  int
+}
+
+func UI() error {
+	t, err := tui.Open()
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	u := &noteUI{t: t, expanded: make(map[string]bool)}
+	u.reload("")
+
+	w, h := t.Size()
+	u.draw()
+	for !u.quit {
+		k, err := t.ReadKey(200 * time.Millisecond)
+		if errors.Is(err, tui.ErrTimeout) {
+			// 窗口大小变化时重绘
+			if nw, nh := t.Size(); nw != w || nh != h {
+				w, h = nw, nh
+				u.draw()
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		u.handle(k)
+		u.draw()
+	}
+	return nil
+}
+
+// 重新读取目录树, 保留展开状态, 光标移到 selected
+func (u *noteUI) reload(selected string) {
+	u.root, u.files = loadTree()
+	u.refreshRows()
+	if selected != "" {
+		u.selectPath(selected)
+	}
+	u.clampCursor()
+	u.previewRel = ""
+}
+
+// 读取存储目录的目录树, 返回根节点和所有文件, 下标与 note l 一致
+func loadTree() (*uiNode, []*uiNode) {
+	root := &uiNode{dir: true, depth: -1}
+	var files []*uiNode
+	var build func(parent *uiNode, dir string)
+	build = func(parent *uiNode, dir string) {
+		for i, entry := range shell.SortedEntries(dir) {
+			n := &uiNode{
+				name:   entry.Name(),
+				dir:    entry.IsDir(),
+				depth:  parent.depth + 1,
+				parent: parent,
+			}
+			if parent.rel == "" {
+				n.rel = n.name
+				n.index = fmt.Sprint(i + 1)
+			} else {
+				n.rel = parent.rel + "/" + n.name
+				n.index = fmt.Sprintf("%s.%d", parent.index, i+1)
+			}
+			parent.children = append(parent.children, n)
+			if n.dir {
+				build(n, filepath.Join(dir, n.name))
+			} else {
+				files = append(files, n)
+			}
+		}
+	}
+	build(root, StorePath)
+	return root, files
+}
+
+func (u *noteUI) refreshRows() {
+	u.rows = u.rows[:0]
+	if u.query != "" {
+		paths := make([]string, len(u.files))
+		for i, f := range u.files {
+			paths[i] = f.rel
+		}
+		for _, m := range tui.Filter(u.query, paths) {
+			u.rows = append(u.rows, uiRow{node: u.files[m.Index], positions: m.Positions})
+		}
+		return
+	}
+	var walk func(n *uiNode)
+	walk = func(n *uiNode) {
+		for _, c := range n.children {
+			u.rows = append(u.rows, uiRow{node: c})
+			if c.dir && u.expanded[c.rel] {
+				walk(c)
+			}
+		}
+	}
+	walk(u.root)
+}
+
+func (u *noteUI) current() *uiNode {
+	if u.cursor < 0 || u.cursor >= len(u.rows) {
+		return nil
+	}
+	return u.rows[u.cursor].node
+}
+
+// 展开上级目录并把光标移到 rel
+func (u *noteUI) selectPath(rel string) {
+	if u.query == "" {
+		for dir := filepath.ToSlash(filepath.Dir(rel)); dir != "." && dir != "/"; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			u.expanded[dir] = true
+		}
+		u.refreshRows()
+	}
+	for i, r := range u.rows {
+		if r.node.rel == rel {
+			u.cursor = i
+			return
+		}
+	}
+}
+
+func (u *noteUI) clampCursor() {
+	if u.cursor >= len(u.rows) {
+		u.cursor = len(u.rows) - 1
+	}
+	if u.cursor < 0 {
+		u.cursor = 0
+	}
+}
+
+func (u *noteUI) move(delta int) {
+	u.cursor += delta
+	u.clampCursor()
+}
+
+func (u *noteUI) handle(k tui.Key) {
+	u.message = ""
+	switch {
+	case u.prompt != nil:
+		u.handlePrompt(k)
+	case u.filtering:
+		u.handleFilter(k)
+	default:
+		u.handleNormal(k)
+	}
+}
+
+func (u *noteUI) handlePrompt(k tui.Key) {
+	p := u.prompt
+	if p.confirm {
+		u.prompt = nil
+		if k.IsRune('y') || k.IsRune('Y') {
+			p.done("y")
+		}
+		return
+	}
+	switch {
+	case k.Is(tui.KeyEsc), k.IsCtrl('c'):
+		u.prompt = nil
+	case k.Is(tui.KeyEnter):
+		u.prompt = nil
+		p.done(strings.TrimSpace(p.value))
+	default:
+		p.value = tui.EditLine(p.value, k)
+	}
+}
+
+func (u *noteUI) handleFilter(k tui.Key) {
+	switch {
+	case k.Is(tui.KeyEsc), k.IsCtrl('c'):
+		u.filtering = false
+		u.query = ""
+	case k.Is(tui.KeyEnter):
+		u.filtering = false
+		return
+	case k.Is(tui.KeyUp), k.IsCtrl('p'):
+		u.move(-1)
+		return
+	case k.Is(tui.KeyDown), k.IsCtrl('n'):
+		u.move(1)
+		return
+	default:
+		u.query = tui.EditLine(u.query, k)
+	}
+	u.refreshRows()
+	u.cursor = 0
+}
+
+func (u *noteUI) handleNormal(k tui.Key) {
+	_, h := u.t.Size()
+	page := max(1, h-3)
+	n := u.current()
+	switch {
+	case k.IsRune('q'), k.IsCtrl('c'):
+		u.quit = true
+	case k.Is(tui.KeyEsc):
+		if u.query != "" {
+			sel := ""
+			if n != nil {
+				sel = n.rel
+			}
+			u.query = ""
+			u.refreshRows()
+			u.selectPath(sel)
+		}
+	case k.IsRune('j'), k.Is(tui.KeyDown), k.IsCtrl('n'):
+		u.move(1)
+	case k.IsRune('k'), k.Is(tui.KeyUp), k.IsCtrl('p'):
+		u.move(-1)
+	case k.Is(tui.KeyPgDn), k.IsCtrl('f'):
+		u.move(page)
+	case k.Is(tui.KeyPgUp), k.IsCtrl('b'):
+		u.move(-page)
+	case k.IsRune('g'), k.Is(tui.KeyHome):
+		u.cursor = 0
+	case k.IsRune('G'), k.Is(tui.KeyEnd):
+		u.cursor = len(u.rows) - 1
+	case k.IsRune('J'):
+		u.previewOff += page / 2
+	case k.IsRune('K'):
+		u.previewOff = max(0, u.previewOff-page/2)
+	case k.IsRune('/'):
+		u.filtering = true
+	case k.IsRune('r'):
+		u.reload(u.selected())
+	case n == nil:
+		if k.IsRune('a') {
+			u.create("")
+		}
+	case k.IsRune('l'), k.Is(tui.KeyRight):
+		if n.dir && u.query == "" {
+			if u.expanded[n.rel] && len(n.children) > 0 {
+				u.move(1)
+			} else {
+				u.toggle(n, true)
+			}
+		}
+	case k.IsRune('h'), k.Is(tui.KeyLeft):
+		if n.dir && u.expanded[n.rel] && u.query == "" {
+			u.toggle(n, false)
+		} else if n.parent != nil && n.parent.rel != "" && u.query == "" {
+			u.selectPath(n.parent.rel)
+		}
+	case k.Is(tui.KeyEnter), k.IsRune(' '):
+		if n.dir {
+			u.toggle(n, !u.expanded[n.rel])
+		} else {
+			u.edit(n.rel)
+		}
+	case k.IsRune('e'):
+		if !n.dir {
+			u.edit(n.rel)
+		}
+	case k.IsRune('a'):
+		dir := n.rel
+		if !n.dir {
+			dir = filepath.ToSlash(filepath.Dir(n.rel))
+		}
+		if dir == "." {
+			dir = ""
+		} else {
+			dir += "/"
+		}
+		u.create(dir)
+	case k.IsRune('m'):
+		u.prompt = &uiPrompt{label: "移动到: ", value: n.rel, done: func(target string) {
+			if target == "" || target == n.rel {
+				return
+			}
+			if err := MoveNoteRel(n.rel, target); err != nil {
+				u.message = shell.BrightRed + "移动失败: " + err.Error()
+				return
+			}
+			u.reload(filepath.ToSlash(filepath.Clean(target)))
+			u.message = "已移动到 " + target
+		}}
+	case k.IsRune('d'):
+		label := fmt.Sprintf("删除 %s?", n.rel)
+		if n.dir {
+			label = fmt.Sprintf("删除目录 %s (包含 %d 个文件)?", n.rel, countFiles(StorePath+n.rel))
+		}
+		u.prompt = &uiPrompt{label: label + " [y/N] ", confirm: true, done: func(string) {
+			item, err := DeleteNoteRel(n.rel)
+			if err != nil {
+				u.message = shell.BrightRed + "删除失败: " + err.Error()
+				return
+			}
+			u.reload("")
+			u.message = "已移到回收站, 恢复执行 note trash restore " + item.ID
+		}}
+	case k.IsRune('c'):
+		u.prompt = &uiPrompt{label: "提交说明: ", done: func(msg string) {
+			if msg == "" {
+				msg = "提交所有修改"
+			}
+			if err := Commit(msg); err != nil {
+				u.message = shell.BrightRed + "提交失败: " + err.Error()
+				return
+			}
+			u.message = "已提交"
+		}}
+	}
+}
+
+func (u *noteUI) selected() string {
+	if n := u.current(); n != nil {
+		return n.rel
+	}
+	return ""
+}
+
+func (u *noteUI) toggle(n *uiNode, open bool) {
+	u.expanded[n.rel] = open
+	u.refreshRows()
+	u.selectPath(n.rel)
+}
+
+func (u *noteUI) create(dir string) {
+	u.prompt = &uiPrompt{label: "新建笔记: ", value: dir, done: func(rel string) {
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			return
+		}
+		u.edit(rel)
+	}}
+}
+
+// 退出全屏界面运行编辑器, 结束后回到原来的位置
+func (u *noteUI) edit(rel string) {
+	u.t.Suspend()
+	editPath(StorePath + rel)
+	if err := u.t.Resume(); err != nil {
+		u.quit = true
+		return
+	}
+	u.reload(rel)
+}
+
+func (u *noteUI) draw() {
+	w, h := u.t.Size()
+	bodyHeight := max(1, h-2)
+	leftWidth := min(max(24, w*2/5), max(1, w-20))
+	rightWidth := max(0, w-leftWidth-1)
+
+	if u.cursor < u.offset {
+		u.offset = u.cursor
+	}
+	if u.cursor >= u.offset+bodyHeight {
+		u.offset = u.cursor - bodyHeight + 1
+	}
+
+	preview := u.loadPreview()
+	if u.previewOff > max(0, len(preview)-1) {
+		u.previewOff = max(0, len(preview)-1)
+	}
+
+	lines := make([]string, 0, h)
+	header := shell.Bold + " note ui " + shell.ResetAll + shell.BrightBlack + StorePath + shell.ResetAll
+	if u.filtering || u.query != "" {
+		cursor := ""
+		if u.filtering {
+			cursor = shell.Reverse + " " + shell.ResetAll
+		}
+		header = fmt.Sprintf(" %s/%s %s%s  %s(%d/%d)%s", shell.BrightYellow, shell.ResetAll, u.query, cursor, shell.BrightBlack, len(u.rows), len(u.files), shell.ResetAll)
+	}
+	lines = append(lines, header)
+	for i := 0; i < bodyHeight; i++ {
+		left := ""
+		if r := u.offset + i; r < len(u.rows) {
+			left = u.renderRow(u.rows[r], r == u.cursor, leftWidth)
+		}
+		right := ""
+		if p := u.previewOff + i; p < len(preview) {
+			right = preview[p]
+		}
+		lines = append(lines, tui.Pad(left, leftWidth)+shell.ResetAll+shell.BrightBlack+"│"+shell.ResetAll+tui.Truncate(right, rightWidth))
+	}
+	lines = append(lines, u.status())
+	u.t.Draw(lines)
+}
+
+func (u *noteUI) renderRow(r uiRow, selected bool, width int) string {
+	n := r.node
+	if u.query != "" {
+		text := n.index + " " + n.rel
+		if selected {
+			return shell.Reverse + tui.Pad(text, width) + shell.ResetAll
+		}
+		return shell.BrightBlack + n.index + shell.ResetAll + " " + tui.Highlight(n.rel, r.positions, shell.BrightYellow+shell.Bold, shell.ResetAll)
+	}
+	marker := "  "
+	if n.dir {
+		marker = "▸ "
+		if u.expanded[n.rel] {
+			marker = "▾ "
+		}
+	}
+	indent := strings.Repeat("  ", n.depth)
+	if selected {
+		return shell.Reverse + tui.Pad(indent+marker+n.index+" "+n.name, width) + shell.ResetAll
+	}
+	color := shell.Yellow
+	if n.dir {
+		color = shell.BrightCyan
+	}
+	return indent + marker + shell.BrightBlack + n.index + shell.ResetAll + " " + color + n.name + shell.ResetAll
+}
+
+func (u *noteUI) status() string {
+	if p := u.prompt; p != nil {
+		text := shell.BrightYellow + p.label + shell.ResetAll + p.value
+		if !p.confirm {
+			text += shell.Reverse + " " + shell.ResetAll
+		}
+		return text
+	}
+	if u.message != "" {
+		return u.message + shell.ResetAll
+	}
+	if u.filtering {
+		return shell.BrightBlack + "输入过滤条件  ↑/↓ 移动  Enter 确定  Esc 取消" + shell.ResetAll
+	}
+	return shell.BrightBlack + uiHelp + shell.ResetAll
+}
+
+// 当前选中项的预览, 文件修改后重新生成
+func (u *noteUI) loadPreview() []string {
+	n := u.current()
+	if n == nil {
+		return nil
+	}
+	info, err := os.Stat(StorePath + n.rel)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if n.rel == u.previewRel && info.ModTime().Equal(u.previewTime) {
+		return u.preview
+	}
+	if n.rel != u.previewRel {
+		u.previewOff = 0
+	}
+	u.previewRel, u.previewTime = n.rel, info.ModTime()
+	u.preview = previewLines(n)
+	return u.preview
+}
+
+// 目录列出子项, 文件高亮显示内容
+func previewLines(n *uiNode) []string {
+	if n.dir {
+		lines := []string{fmt.Sprintf("%s%s/%s  %d 个文件", shell.BrightCyan, n.rel, shell.ResetAll, countFiles(StorePath+n.rel))}
+		for _, c := range n.children {
+			name := c.name
+			if c.dir {
+				name = shell.BrightCyan + name + "/" + shell.ResetAll
+			}
+			lines = append(lines, " "+c.index+" "+name)
+		}
+		return lines
+	}
+	f, err := os.Open(StorePath + n.rel)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, previewLimit))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if crypt.IsEncrypted(data) {
+		// 在全屏界面中无法输入密码, 只有已知密码时才解密
+		if cachedPassphrase == "" && os.Getenv(EnvPassphrase) == "" {
+			return []string{shell.BrightBlack + "加密笔记, 按 e 编辑" + shell.ResetAll}
+		}
+		if data, err = decodeNote(data); err != nil {
+			return []string{err.Error()}
+		}
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return []string{shell.BrightBlack + "二进制文件" + shell.ResetAll}
+	}
+	var buf bytes.Buffer
+	highlightNote(&buf, n.rel, data)
+	return tui.SplitLines(buf.String())
+}
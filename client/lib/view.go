@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"fmt"
+	"github.com/alecthomas/chroma/styles"
+	"golang.org/x/term"
+	"io"
+	"note/client/markdown"
+	"note/shell"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// note view: Markdown 渲染, 没有扩展名的笔记按内容判断语言, 超过一屏时用分页器显示
+
+type ViewOptions struct {
+	Raw   bool   // 原样输出, 不渲染不着色
+	Style string // 代码着色样式, 为空时用 monokai
+}
+
+func ViewNote(fileName string, opt ViewOptions) error {
+	return viewPath(ResolvePath(fileName), opt)
+}
+
+// 查看存储目录下的完整路径
+func viewPath(path string, opt ViewOptions) error {
+	if err := checkStyle(opt.Style); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		data, err = decodeNote(data)
+	}
+	if err != nil {
+		return err
+	}
+	if opt.Raw {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		// 不是终端时不分页
+		width, height = 0, 0
+	}
+	out := renderNote(path, data, opt.Style, width)
+	if height > 0 && strings.Count(out, "\n") >= height {
+		return page(out)
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func checkStyle(style string) error {
+	if style != "" && styles.Registry[style] == nil {
+		return fmt.Errorf("未知的样式 %s, 可选: %s", style, strings.Join(styles.Names(), ", "))
+	}
+	return nil
+}
+
+func printNote(path string, data []byte) {
+	width, _, _ := term.GetSize(int(os.Stdout.Fd()))
+	fmt.Print(renderNote(path, data, "", width))
+}
+
+func highlightNote(w io.Writer, path string, data []byte) {
+	io.WriteString(w, renderNote(path, data, "", 0))
+}
+
+func renderNote(path string, data []byte, style string, width int) string {
+	if style == "" {
+		style = markdown.DefaultStyle
+	}
+	switch lang := markdown.Language(path, data); lang {
+	case markdown.Markdown:
+		return markdown.Render(data, markdown.Options{Width: width, Style: style})
+	case markdown.Text:
+		return string(data)
+	default:
+		return markdown.Highlight(string(data), lang, style)
+	}
+}
+
+// 用 $PAGER 分页, 默认 less -R, 分页器不可用时直接输出
+func page(out string) error {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err != nil {
+			fmt.Print(out)
+			return nil
+		}
+		pager = "less -R"
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(out)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		shell.Log(err)
+		fmt.Print(out)
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+package lib
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"note/client/crypt"
+	"note/client/git"
+	"note/client/link"
+	"note/client/markdown"
+	"note/client/meta"
+	"note/shell"
+	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// note serve: 只读的网页浏览, 左侧目录树, 笔记渲染为 HTML, 支持全文搜索和查看历史版本.
+// 设置令牌后需要先用 ?token= 访问一次, 之后由 cookie 保持登录
+
+const EnvServeToken = "NOTE_SERVE_TOKEN"
+
+const tokenCookie = "note_token"
+
+type WebOptions struct {
+	Addr  string
+	Token string // 为空时不校验
+	Style string // 代码着色样式
+}
+
+type webServer struct {
+	opt WebOptions
+}
+
+// 网页布局, 在导出页面的样式基础上增加侧边栏
+const webCSS = `body{display:flex;min-height:100vh}
+aside{width:300px;flex:none;border-right:1px solid #d0d7de;background:#f6f8fa;padding:16px;box-sizing:border-box;overflow:auto;max-height:100vh;position:sticky;top:0}
+aside form{display:flex;margin-bottom:12px}aside input{flex:1;padding:4px 8px;border:1px solid #d0d7de;border-radius:6px}
+aside .tree ul{padding-left:1em}aside .tree>ul{padding-left:0}aside details>summary{cursor:pointer;list-style:none}
+aside .current{font-weight:bold}
+main{flex:1;min-width:0}
+mark{background:#fff8c5}
+.results li{margin-bottom:8px}.results .line{color:#57606a;font-size:13px;display:block}
+.history td{font-size:13px}.history .deleted{color:#cf222e}
+`
+
+func ServeWeb(opt WebOptions) error {
+	if err := checkStyle(opt.Style); err != nil {
+		return err
+	}
+	if opt.Style == "" {
+		opt.Style = markdown.DefaultHTMLStyle
+	}
+	if opt.Token == "" && !loopback(opt.Addr) {
+		fmt.Printf("%s警告: 监听在 %s 且没有设置 --token, 局域网内的任何人都能读取笔记%s\n", shell.BrightYellow, opt.Addr, shell.ResetAll)
+	}
+	s := &webServer{opt: opt}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /n/{path...}", s.handleNote)
+	mux.HandleFunc("GET /history/{path...}", s.handleHistory)
+	mux.HandleFunc("GET /search", s.handleSearch)
+	srv := &http.Server{
+		Addr:              opt.Addr,
+		Handler:           s.auth(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ln, err := net.Listen("tcp", opt.Addr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("网页浏览已启动: http://%s/\n", ln.Addr())
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("网页浏览已停止")
+	return nil
+}
+
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// 令牌校验: ?token=, cookie 或 Authorization: Bearer, 通过 ?token= 登录后跳转去掉地址中的令牌
+func (s *webServer) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		if s.opt.Token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if t := r.URL.Query().Get("token"); t != "" {
+			if !s.validToken(t) {
+				s.unauthorized(w)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: tokenCookie, Value: t, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+			q := r.URL.Query()
+			q.Del("token")
+			u := *r.URL
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+		token := ""
+		if c, err := r.Cookie(tokenCookie); err == nil {
+			token = c.Value
+		}
+		if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = t
+		}
+		if !s.validToken(token) {
+			s.unauthorized(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *webServer) validToken(t string) bool {
+	return subtle.ConstantTimeCompare([]byte(t), []byte(s.opt.Token)) == 1
+}
+
+func (s *webServer) unauthorized(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusUnauthorized)
+	body := `<h1>需要访问令牌</h1>
+<form method="get" action="/"><input type="password" name="token" placeholder="令牌" autofocus> <button>进入</button></form>
+`
+	fmt.Fprint(w, pageHTML("需要访问令牌", "", body))
+}
+
+// 地址中的笔记路径, 不允许访问隐藏目录和存储目录之外的文件
+func notePathParam(r *http.Request) (string, bool) {
+	rel := strings.TrimPrefix(path.Clean("/"+r.PathValue("path")), "/")
+	if rel == "" {
+		return "", false
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "", false
+		}
+	}
+	return rel, true
+}
+
+func noteURL(rel string) string {
+	return (&url.URL{Path: "/n/" + rel}).String()
+}
+
+func (s *webServer) page(w http.ResponseWriter, title, current, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n<style>\n" + markdown.CSS + webCSS + "</style>\n</head>\n<body>\n")
+	sb.WriteString("<aside>\n<form action=\"/search\"><input name=\"q\" placeholder=\"全文搜索\"></form>\n")
+	sb.WriteString(`<p class="meta"><a href="/">笔记</a></p>` + "\n")
+	sb.WriteString(webTree(current))
+	sb.WriteString("</aside>\n<main>\n" + body + "</main>\n</body>\n</html>\n")
+	fmt.Fprint(w, sb.String())
+}
+
+func (s *webServer) error(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	s.page(w, msg, "", "<h1>"+html.EscapeString(msg)+"</h1>\n")
+}
+
+// 与 note l 一致的目录树, 当前笔记所在的目录展开
+func webTree(current string) string {
+	root, _ := loadTree()
+	var walk func(n *uiNode) string
+	walk = func(n *uiNode) string {
+		var sb strings.Builder
+		sb.WriteString("<ul>\n")
+		for _, c := range n.children {
+			index := `<span class="index">` + c.index + "</span>"
+			if c.dir {
+				open := ""
+				if strings.HasPrefix(current, c.rel+"/") {
+					open = " open"
+				}
+				fmt.Fprintf(&sb, "<li><details%s><summary>%s%s/</summary>\n%s</details></li>\n", open, index, html.EscapeString(c.name), walk(c))
+				continue
+			}
+			class := ""
+			if c.rel == current {
+				class = ` class="current"`
+			}
+			fmt.Fprintf(&sb, "<li>%s<a%s href=\"%s\">%s</a></li>\n", index, class, noteURL(c.rel), html.EscapeString(c.name))
+		}
+		sb.WriteString("</ul>\n")
+		return sb.String()
+	}
+	return `<nav class="tree">` + "\n" + walk(root) + "</nav>\n"
+}
+
+func (s *webServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.page(w, "笔记", "", "<h1>笔记</h1>\n<p class=\"meta\">在左侧选择笔记, 或者输入关键字全文搜索</p>\n")
+}
+
+// 笔记页面, ?rev=N 查看第 N 个历史版本, 图片等二进制文件原样返回
+func (s *webServer) handleNote(w http.ResponseWriter, r *http.Request) {
+	rel, ok := notePathParam(r)
+	if !ok {
+		s.error(w, http.StatusNotFound, "没有找到笔记")
+		return
+	}
+	var data []byte
+	var err error
+	title := rel
+	if rev := r.URL.Query().Get("rev"); rev != "" {
+		var g *git.GitHubClient
+		var v git.Revision
+		if g, err = git.NewClient(StorePath, RemoteURL, ""); err == nil {
+			if v, err = g.Resolve(rel, rev); err == nil {
+				data, err = g.FileAt(v)
+				title = fmt.Sprintf("%s@%s %s", rel, rev, v.Short())
+			}
+		}
+	} else {
+		var info os.FileInfo
+		if info, err = os.Stat(StorePath + rel); err == nil && info.IsDir() {
+			s.error(w, http.StatusNotFound, "没有找到笔记")
+			return
+		}
+		data, err = os.ReadFile(StorePath + rel)
+	}
+	if err != nil {
+		s.error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if isBinary(data) {
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Write(data)
+		return
+	}
+
+	resolver, _ := link.NewResolver(StorePath)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<p class="meta">%s · <a href="/history/%s">历史版本</a></p>`+"\n", html.EscapeString(title), html.EscapeString(strings.TrimPrefix(noteURL(rel), "/n/")))
+	// 网页中无法输入密码, 加密笔记不显示
+	if crypt.IsEncrypted(data) {
+		sb.WriteString("<p>加密笔记, 请在终端中用 note view 查看</p>\n")
+		s.page(w, title, rel, sb.String())
+		return
+	}
+	switch lang := markdown.Language(rel, data); lang {
+	case markdown.Markdown:
+		if t := meta.Title(data); t != "" {
+			title = t
+		}
+		_, body, _ := meta.Parse(data)
+		sb.WriteString(markdown.RenderHTML(body, markdown.HTMLOptions{
+			Style: s.opt.Style,
+			Link:  func(u string, kind markdown.InlineKind) string { return webLink(resolver, rel, u, kind) },
+		}))
+	case markdown.Text:
+		sb.WriteString("<pre>" + html.EscapeString(string(data)) + "</pre>\n")
+	default:
+		sb.WriteString(markdown.HighlightHTML(string(data), lang, s.opt.Style))
+	}
+	s.page(w, title, rel, sb.String())
+}
+
+// [[链接]] 和指向存储目录中文件的相对链接改为笔记页面地址
+func webLink(resolver *link.Resolver, from, u string, kind markdown.InlineKind) string {
+	if kind == markdown.InlineWikiLink {
+		if resolver == nil {
+			return ""
+		}
+		rel, ok := resolver.Resolve(u)
+		if !ok {
+			return ""
+		}
+		href := noteURL(rel)
+		if i := strings.Index(u, "#"); i > 0 {
+			href += "#" + markdown.Slug(u[i+1:])
+		}
+		return href
+	}
+	rel, fragment, ok := localTarget(from, u)
+	if !ok {
+		return u
+	}
+	if _, err := os.Stat(StorePath + rel); err != nil {
+		return u
+	}
+	href := noteURL(rel)
+	if fragment != "" {
+		href += "#" + fragment
+	}
+	return href
+}
+
+func (s *webServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	rel, ok := notePathParam(r)
+	if !ok {
+		s.error(w, http.StatusNotFound, "没有找到笔记")
+		return
+	}
+	g, err := git.NewClient(StorePath, RemoteURL, "")
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	revs, err := g.History(rel)
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<h1>%s 的历史版本</h1>\n", html.EscapeString(rel))
+	if len(revs) == 0 {
+		sb.WriteString("<p>没有提交记录</p>\n")
+	} else {
+		sb.WriteString("<table class=\"history\">\n<tr><th>#</th><th>时间</th><th>提交</th><th>说明</th><th>作者</th></tr>\n")
+		for i, v := range revs {
+			num := strconv.Itoa(i + 1)
+			version := fmt.Sprintf(`<a href="%s?rev=%s">%s</a>`, noteURL(rel), num, v.Short())
+			if v.Deleted {
+				version = `<span class="deleted">已删除</span>`
+			}
+			message := html.EscapeString(v.Message)
+			if v.Path != rel {
+				message += ` <span class="meta">` + html.EscapeString(v.Path) + "</span>"
+			}
+			fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				num, v.When.Format("2006-01-02 15:04:05"), version, message, html.EscapeString(v.Author))
+		}
+		sb.WriteString("</table>\n")
+	}
+	s.page(w, rel+" 的历史版本", rel, sb.String())
+}
+
+// 全文搜索, 与 note s 使用同一个索引, 但不解密加密笔记, 不会在服务端等待输入密码
+func (s *webServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	results, err := searchPlainNotes(q)
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<h1>搜索: %s</h1>\n", html.EscapeString(q))
+	if len(results) == 0 {
+		sb.WriteString("<p>没有找到相关笔记</p>\n")
+	}
+	sb.WriteString("<ol class=\"results\">\n")
+	for _, res := range results {
+		re := termsRegex(res.Terms)
+		fmt.Fprintf(&sb, "<li><a href=\"%s\">%s</a>\n", noteURL(res.Path), markTerms(res.Path, re))
+		for _, l := range res.Lines {
+			fmt.Fprintf(&sb, "<span class=\"line\">%d: %s</span>\n", l.Num, markTerms(strings.TrimSpace(l.Text), re))
+		}
+		sb.WriteString("</li>\n")
+	}
+	sb.WriteString("</ol>\n")
+	s.page(w, "搜索: "+q, "", sb.String())
+}
+
+// 转义并用 <mark> 标出命中的关键字
+func markTerms(text string, re *regexp.Regexp) string {
+	if re == nil {
+		return html.EscapeString(text)
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		sb.WriteString(html.EscapeString(text[last:m[0]]))
+		sb.WriteString("<mark>" + html.EscapeString(text[m[0]:m[1]]) + "</mark>")
+		last = m[1]
+	}
+	sb.WriteString(html.EscapeString(text[last:]))
+	return sb.String()
+}
@@ -0,0 +1,99 @@
+package link
+
+import (
+	"bytes"
+	"strings"
+)
+
+// 笔记中的 [[路径或标题]] 链接, 也支持 [[目标|显示文字]] 和 [[@笔记ID]],
+// 代码块中的内容不解析
+
+type Link struct {
+	Target string // 链接目标
+	Alias  string // 显示文字, 可为空
+	Line   int    // 所在行号, 从 1 开始
+	start  int    // Target 在内容中的起止位置
+	end    int
+}
+
+func Parse(content []byte) []Link {
+	var links []Link
+	scan(content, func(l Link) {
+		links = append(links, l)
+	})
+	return links
+}
+
+// 重写链接目标, fn 返回新的目标和是否需要替换
+func Rewrite(content []byte, fn func(l Link) (string, bool)) ([]byte, bool) {
+	var buf bytes.Buffer
+	last := 0
+	changed := false
+	scan(content, func(l Link) {
+		target, ok := fn(l)
+		if !ok || target == l.Target {
+			return
+		}
+		buf.Write(content[last:l.start])
+		buf.WriteString(target)
+		last = l.end
+		changed = true
+	})
+	if !changed {
+		return content, false
+	}
+	buf.Write(content[last:])
+	return buf.Bytes(), true
+}
+
+func scan(content []byte, fn func(l Link)) {
+	inFence := false
+	offset := 0
+	for i, line := range strings.SplitAfter(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence {
+			scanLine(line, offset, i+1, fn)
+		}
+		offset += len(line)
+	}
+}
+
+func scanLine(line string, offset, lineNum int, fn func(l Link)) {
+	pos := 0
+	for {
+		open := strings.Index(line[pos:], "[[")
+		if open == -1 {
+			return
+		}
+		open += pos + 2
+		closing := strings.Index(line[open:], "]]")
+		if closing == -1 {
+			return
+		}
+		closing += open
+		inner := line[open:closing]
+		pos = closing + 2
+		if strings.ContainsAny(inner, "[\n") {
+			continue
+		}
+		target, alias := inner, ""
+		if bar := strings.Index(inner, "|"); bar != -1 {
+			target, alias = inner[:bar], inner[bar+1:]
+		}
+		trimmed := strings.TrimSpace(target)
+		if trimmed == "" {
+			continue
+		}
+		start := open + strings.Index(target, trimmed)
+		fn(Link{
+			Target: trimmed,
+			Alias:  alias,
+			Line:   lineNum,
+			start:  offset + start,
+			end:    offset + start + len(trimmed),
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package link
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	type want struct {
+		target, alias string
+		line          int
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    []want
+	}{
+		{name: "路径和别名", content: "见 [[a]] 和 [[ b.md | B ]]", want: []want{{"a", "", 1}, {"b.md", " B ", 1}}},
+		{name: "锚点", content: "[[周会#议程|会议]]", want: []want{{"周会#议程", "会议", 1}}},
+		{name: "笔记ID", content: "x\r\n[[@k3x9]]", want: []want{{"@k3x9", "", 2}}},
+		{name: "代码块", content: "```\n[[x]]\n```\n[[y]]\n~~~go\n[[z]]\n~~~", want: []want{{"y", "", 4}}},
+		{name: "空目标", content: "[[]] [[ |别名]] [[ok]]", want: []want{{"ok", "", 1}}},
+		{name: "未闭合", content: "[[a\n]] [[b", want: nil},
+		{name: "单括号", content: "[a](b.md) [[c]]", want: []want{{"c", "", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := Parse([]byte(tt.content))
+			if len(links) != len(tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", links, tt.want)
+			}
+			for i, l := range links {
+				w := tt.want[i]
+				if l.Target != w.target || l.Alias != w.alias || l.Line != w.line {
+					t.Errorf("links[%d] = %+v, want %+v", i, l, w)
+				}
+				// 起止位置要正好是目标, 重写时才不会改坏其它内容
+				if got := tt.content[l.start:l.end]; got != l.Target {
+					t.Errorf("links[%d] offsets = %q, want %q", i, got, l.Target)
+				}
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	// a 移动到 dir/a, 保留 #锚点
+	move := func(l Link) (string, bool) {
+		name, fragment, _ := strings.Cut(l.Target, "#")
+		if name != "a" {
+			return "", false
+		}
+		if fragment != "" {
+			return "dir/a#" + fragment, true
+		}
+		return "dir/a", true
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		changed bool
+	}{
+		{name: "替换", content: "[[a]] [[b]]\n", want: "[[dir/a]] [[b]]\n", changed: true},
+		{name: "保留空白和别名", content: "前 [[ a |显示]] 后", want: "前 [[ dir/a |显示]] 后", changed: true},
+		{name: "锚点", content: "[[a#小节|A]]", want: "[[dir/a#小节|A]]", changed: true},
+		{name: "多行", content: "中文\r\n[[a]]\r\n[[a]]", want: "中文\r\n[[dir/a]]\r\n[[dir/a]]", changed: true},
+		{name: "代码块不改", content: "```\n[[a]]\n```\n", want: "```\n[[a]]\n```\n"},
+		{name: "没有链接", content: "[[b]] [a](a)", want: "[[b]] [a](a)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := Rewrite([]byte(tt.content), move)
+			if string(got) != tt.want || changed != tt.changed {
+				t.Errorf("Rewrite() = %q, %v, want %q, %v", got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+
+	// 返回相同目标不算修改
+	if _, changed := Rewrite([]byte("[[a]]"), func(l Link) (string, bool) { return l.Target, true }); changed {
+		t.Error("Rewrite() with same target changed = true")
+	}
+}
@@ -0,0 +1,101 @@
+package link
+
+import (
+	"note/client/meta"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 将链接目标解析为笔记的相对路径, 优先级: @ID > 相对路径 > 省略扩展名的路径 > 标题 > 文件名
+
+type Resolver struct {
+	root    string
+	files   []string
+	byPath  map[string]string // 小写相对路径 -> 相对路径
+	byNoExt map[string]string // 去掉扩展名的小写相对路径
+	byTitle map[string]string // front matter 中的小写标题
+	byName  map[string]string // 去掉扩展名的小写文件名
+	ids     *meta.IDs
+}
+
+func NewResolver(root string) (*Resolver, error) {
+	r := &Resolver{
+		root:    root,
+		byPath:  make(map[string]string),
+		byNoExt: make(map[string]string),
+		byTitle: make(map[string]string),
+		byName:  make(map[string]string),
+	}
+	ids, err := meta.LoadIDs(root)
+	if err != nil {
+		return nil, err
+	}
+	r.ids = ids
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		r.add(filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(r.files)
+	return r, err
+}
+
+func (r *Resolver) add(rel string) {
+	r.files = append(r.files, rel)
+	lower := strings.ToLower(rel)
+	r.byPath[lower] = rel
+	setOnce(r.byNoExt, strings.TrimSuffix(lower, filepath.Ext(lower)), rel)
+	name := filepath.Base(lower)
+	setOnce(r.byName, strings.TrimSuffix(name, filepath.Ext(name)), rel)
+	if meta.Supports(rel) {
+		if m, err := meta.ParseFile(filepath.Join(r.root, rel)); err == nil && m != nil && m.Title != "" {
+			setOnce(r.byTitle, strings.ToLower(m.Title), rel)
+		}
+	}
+}
+
+// 同名时保留排序靠前的文件, 保证解析结果稳定
+func setOnce(m map[string]string, key, rel string) {
+	if old, ok := m[key]; !ok || rel < old {
+		m[key] = rel
+	}
+}
+
+// 存储目录下的所有笔记
+func (r *Resolver) Files() []string {
+	return r.files
+}
+
+func (r *Resolver) Resolve(target string) (string, bool) {
+	target = strings.TrimSpace(target)
+	// 去掉 #标题 锚点
+	if i := strings.Index(target, "#"); i > 0 {
+		target = target[:i]
+	}
+	if id, ok := strings.CutPrefix(target, "@"); ok {
+		rel, ok := r.ids.Path(id)
+		return rel, ok
+	}
+	key := strings.ToLower(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(target)), "/"))
+	for _, m := range []map[string]string{r.byPath, r.byNoExt, r.byTitle, r.byName} {
+		if rel, ok := m[key]; ok {
+			return rel, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,70 @@
+package link
+
+import (
+	"note/client/meta"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"b.md":         "",
+		"b.md.txt":     "",
+		"x.md":         "",
+		"y.md":         "---\ntitle: x\n---\n",
+		"docs/z.md":    "",
+		"t.md":         "---\ntitle: Z\n---\n",
+		"docs/only.md": "",
+		"n/dup.md":     "",
+		"m/dup.md":     "",
+		"notes/k.md":   "",
+		".trash/q.md":  "",
+		meta.IDFile:    "k3x9: notes/k.md\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := NewResolver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		want   string // 为空表示找不到
+	}{
+		{target: "@k3x9", want: "notes/k.md"},
+		{target: "@K3X9#小节", want: "notes/k.md"},
+		{target: "@nope", want: ""},
+		{target: "b.md", want: "b.md"}, // 路径优先于省略扩展名的 b.md.txt
+		{target: "x", want: "x.md"},    // 省略扩展名优先于标题为 x 的 y.md
+		{target: "z", want: "t.md"},    // 标题优先于文件名 docs/z.md
+		{target: "only", want: "docs/only.md"},
+		{target: "dup", want: "m/dup.md"}, // 同名取排序靠前的
+		{target: "DOCS/Only.MD", want: "docs/only.md"},
+		{target: "/docs/only", want: "docs/only.md"},
+		{target: " docs/./only#小节 ", want: "docs/only.md"},
+		{target: "q", want: ""}, // 隐藏目录不参与解析
+		{target: "missing", want: ""},
+	}
+	for _, tt := range tests {
+		got, ok := r.Resolve(tt.target)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.target, got, ok, tt.want)
+		}
+	}
+
+	want := []string{"b.md", "b.md.txt", "docs/only.md", "docs/z.md", "m/dup.md", "n/dup.md", "notes/k.md", "t.md", "x.md", "y.md"}
+	if got := r.Files(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %v, want %v", got, want)
+	}
+}
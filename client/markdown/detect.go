@@ -0,0 +1,66 @@
+package markdown
+
+import (
+	"github.com/alecthomas/chroma/lexers"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 语言检测: 有扩展名时按扩展名, 没有扩展名时按内容判断.
+// 返回 chroma 的 lexer 名称, Markdown 返回 "markdown", 纯文本返回 "text"
+
+const (
+	Markdown = "markdown"
+	Text     = "text"
+)
+
+var (
+	shebangRegex = regexp.MustCompile(`^#!\s*(?:/usr/bin/env\s+)?(?:\S*/)?(\w+)`)
+	// 标题, 列表, 待办, 引用, 代码块, 表格, 链接等 Markdown 特征
+	markdownRegex = regexp.MustCompile(`(?m)^(?:#{1,6}\s+\S|\s*[-*+]\s+\S|\s*\d+[.)]\s+\S|>\s|` + "```" + `|~~~|\|.*\|\s*$)|\[[^\]]+\]\([^)]+\)|\*\*[^*]+\*\*`)
+)
+
+var interpreters = map[string]string{
+	"sh": "bash", "bash": "bash", "zsh": "bash", "dash": "bash",
+	"python": "python", "python3": "python", "node": "javascript",
+	"ruby": "ruby", "perl": "perl", "php": "php", "lua": "lua", "fish": "fish",
+}
+
+func Language(path string, data []byte) string {
+	name := filepath.Base(path)
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".md", ".markdown":
+		return Markdown
+	case ".txt":
+		return Text
+	case "":
+	default:
+		if l := lexers.Match(name); l != nil {
+			return l.Config().Name
+		}
+		return Text
+	}
+	// Makefile, Dockerfile 等按文件名识别
+	if l := lexers.Match(name); l != nil {
+		return l.Config().Name
+	}
+	text := string(data)
+	if m := shebangRegex.FindStringSubmatch(text); m != nil {
+		if lang, ok := interpreters[strings.TrimRight(m[1], "0123456789.")]; ok {
+			return lang
+		}
+		if lang, ok := interpreters[m[1]]; ok {
+			return lang
+		}
+	}
+	// 笔记默认是 Markdown, 有 front matter 或 Markdown 特征时直接认定
+	if strings.HasPrefix(text, "---\n") || markdownRegex.MatchString(text) {
+		return Markdown
+	}
+	if l := lexers.Analyse(text); l != nil {
+		return l.Config().Name
+	}
+	// 普通文本按 Markdown 渲染, 段落原样输出, 不会被当成代码着色
+	return Markdown
+}
@@ -0,0 +1,256 @@
+package markdown
+
+import (
+	"fmt"
+	"github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	stdhtml "html"
+	"strings"
+	"unicode"
+)
+
+// HTML 渲染, 用于导出和网页浏览. 代码块用 chroma 生成内联样式, 不依赖外部 CSS
+
+type HTMLOptions struct {
+	Style string // 代码块的 chroma 样式, 为空时用 github
+	// 重写链接和图片地址, kind 为 InlineWikiLink 时 url 是 [[ ]] 中的目标, 返回空字符串时按普通文字输出.
+	// 为空时普通链接原样输出, wiki 链接按文字输出
+	Link func(url string, kind InlineKind) string
+}
+
+const DefaultHTMLStyle = "github"
+
+// 页面的基础样式, 导出和网页浏览共用
+const CSS = `body{margin:0;font:15px/1.6 -apple-system,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;color:#24292f;background:#fff}
+main{max-width:860px;margin:0 auto;padding:24px 32px}
+a{color:#0969da;text-decoration:none}a:hover{text-decoration:underline}
+h1,h2{border-bottom:1px solid #d8dee4;padding-bottom:.3em}
+code{background:#f0f2f4;border-radius:4px;padding:.1em .35em;font:13px/1.45 ui-monospace,Menlo,Consolas,monospace}
+pre{border-radius:6px;padding:12px 16px;overflow:auto;font:13px/1.45 ui-monospace,Menlo,Consolas,monospace}
+pre code{background:none;padding:0}
+blockquote{margin:0;padding:0 1em;color:#57606a;border-left:4px solid #d0d7de}
+table{border-collapse:collapse}th,td{border:1px solid #d0d7de;padding:4px 12px}th{background:#f6f8fa}
+img{max-width:100%}
+li.task{list-style:none}li.task input{margin:0 .4em 0 -1.4em}li.done{color:#8c959f;text-decoration:line-through}
+.missing{color:#cf222e;border-bottom:1px dashed #cf222e}
+.meta{color:#57606a;font-size:13px}
+.tree ul{list-style:none;padding-left:1.2em}.tree>ul{padding-left:0}.tree .index{color:#8c959f;margin-right:.5em;font-size:12px}
+`
+
+type htmlRenderer struct {
+	opt HTMLOptions
+	sb  strings.Builder
+}
+
+// 渲染正文, 不包括 front matter
+func RenderHTML(src []byte, opt HTMLOptions) string {
+	if opt.Style == "" {
+		opt.Style = DefaultHTMLStyle
+	}
+	r := &htmlRenderer{opt: opt}
+	r.blocks(Parse(src).Blocks)
+	return r.sb.String()
+}
+
+// 代码文件整体着色
+func HighlightHTML(code, lang, style string) string {
+	if style == "" {
+		style = DefaultHTMLStyle
+	}
+	return highlightHTML(code, lang, style)
+}
+
+func (r *htmlRenderer) blocks(blocks []Block) {
+	for i := 0; i < len(blocks); i++ {
+		b := blocks[i]
+		switch b.Kind {
+		case BlockParagraph:
+			r.sb.WriteString("<p>")
+			for j, l := range b.Lines {
+				if j > 0 {
+					r.sb.WriteString("<br>\n")
+				}
+				r.sb.WriteString(r.inline(ParseInline(l)))
+			}
+			r.sb.WriteString("</p>\n")
+		case BlockHeading:
+			nodes := ParseInline(b.Lines[0])
+			fmt.Fprintf(&r.sb, "<h%d id=\"%s\">%s</h%d>\n", b.Level, Slug(PlainText(nodes)), r.inline(nodes), b.Level)
+		case BlockRule:
+			r.sb.WriteString("<hr>\n")
+		case BlockQuote:
+			r.sb.WriteString("<blockquote>\n")
+			r.blocks(b.Children)
+			r.sb.WriteString("</blockquote>\n")
+		case BlockCode:
+			r.sb.WriteString(highlightHTML(b.Code, b.Lang, r.opt.Style))
+		case BlockTable:
+			r.table(b)
+		case BlockListItem:
+			end := i
+			for end < len(blocks) && blocks[end].Kind == BlockListItem {
+				end++
+			}
+			r.list(blocks[i:end])
+			i = end - 1
+		}
+	}
+}
+
+// 连续的列表项按缩进层级嵌套
+func (r *htmlRenderer) list(items []Block) {
+	type open struct {
+		level int
+		tag   string
+	}
+	var stack []open
+	closeTop := func() {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		fmt.Fprintf(&r.sb, "</li>\n</%s>\n", top.tag)
+	}
+	for _, item := range items {
+		for len(stack) > 0 && stack[len(stack)-1].level > item.Level {
+			closeTop()
+		}
+		tag := "ul"
+		if item.Ordered() {
+			tag = "ol"
+		}
+		// 同一层级从无序列表换成有序列表时另起一个列表
+		if n := len(stack); n > 0 && stack[n-1].level == item.Level && stack[n-1].tag != tag {
+			closeTop()
+		}
+		switch {
+		case len(stack) == 0 || stack[len(stack)-1].level < item.Level:
+			stack = append(stack, open{item.Level, tag})
+			if tag == "ol" {
+				fmt.Fprintf(&r.sb, "<ol start=\"%s\">\n", strings.TrimRight(item.Marker, ".)"))
+			} else {
+				r.sb.WriteString("<ul>\n")
+			}
+		default:
+			r.sb.WriteString("</li>\n")
+		}
+		text := r.inline(ParseInline(item.Lines[0]))
+		switch item.Task {
+		case 1:
+			fmt.Fprintf(&r.sb, "<li class=\"task\"><input type=\"checkbox\" disabled>%s", text)
+		case 2:
+			fmt.Fprintf(&r.sb, "<li class=\"task done\"><input type=\"checkbox\" checked disabled>%s", text)
+		default:
+			fmt.Fprintf(&r.sb, "<li>%s", text)
+		}
+	}
+	for len(stack) > 0 {
+		closeTop()
+	}
+}
+
+func (r *htmlRenderer) table(b Block) {
+	aligns := map[Align]string{AlignLeft: "", AlignCenter: ` style="text-align:center"`, AlignRight: ` style="text-align:right"`}
+	r.sb.WriteString("<table>\n<thead><tr>")
+	for j, c := range b.Header {
+		fmt.Fprintf(&r.sb, "<th%s>%s</th>", aligns[b.Aligns[j]], r.inline(ParseInline(c)))
+	}
+	r.sb.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range b.Rows {
+		r.sb.WriteString("<tr>")
+		for j, c := range row {
+			fmt.Fprintf(&r.sb, "<td%s>%s</td>", aligns[b.Aligns[j]], r.inline(ParseInline(c)))
+		}
+		r.sb.WriteString("</tr>\n")
+	}
+	r.sb.WriteString("</tbody>\n</table>\n")
+}
+
+func (r *htmlRenderer) link(url string, kind InlineKind) string {
+	// 笔记可能来自别人, 不输出脚本链接
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(url)), "javascript:") {
+		return "#"
+	}
+	if r.opt.Link == nil {
+		if kind == InlineWikiLink {
+			return ""
+		}
+		return url
+	}
+	return r.opt.Link(url, kind)
+}
+
+func (r *htmlRenderer) inline(nodes []Inline) string {
+	var sb strings.Builder
+	esc := stdhtml.EscapeString
+	for _, n := range nodes {
+		switch n.Kind {
+		case InlineText:
+			sb.WriteString(esc(n.Text))
+		case InlineCode:
+			sb.WriteString("<code>" + esc(n.Text) + "</code>")
+		case InlineStrong:
+			sb.WriteString("<strong>" + r.inline(n.Children) + "</strong>")
+		case InlineEmph:
+			sb.WriteString("<em>" + r.inline(n.Children) + "</em>")
+		case InlineStrike:
+			sb.WriteString("<del>" + r.inline(n.Children) + "</del>")
+		case InlineImage:
+			fmt.Fprintf(&sb, "<img src=\"%s\" alt=\"%s\">", esc(r.link(n.URL, InlineImage)), esc(n.Text))
+		case InlineLink:
+			text := r.inline(n.Children)
+			if len(n.Children) == 0 {
+				text = esc(n.URL)
+			}
+			fmt.Fprintf(&sb, "<a href=\"%s\">%s</a>", esc(r.link(n.URL, InlineLink)), text)
+		case InlineWikiLink:
+			text := n.Text
+			if text == "" {
+				text = n.URL
+			}
+			if href := r.link(n.URL, InlineWikiLink); href != "" {
+				fmt.Fprintf(&sb, "<a href=\"%s\">%s</a>", esc(href), esc(text))
+			} else {
+				fmt.Fprintf(&sb, "<span class=\"missing\" title=\"找不到笔记\">%s</span>", esc(text))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func highlightHTML(code, lang, style string) string {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := lexer.Tokenise(nil, code+"\n")
+	if err != nil {
+		return "<pre><code>" + stdhtml.EscapeString(code) + "</code></pre>\n"
+	}
+	var sb strings.Builder
+	if err := html.New().Format(&sb, styles.Get(style), it); err != nil {
+		return "<pre><code>" + stdhtml.EscapeString(code) + "</code></pre>\n"
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// 标题锚点, 保留中文, 空白和标点替换为 -
+func Slug(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return sb.String()
+}
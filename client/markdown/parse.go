@@ -0,0 +1,444 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 按行解析 Markdown, 得到块和行内元素, 终端和 HTML 渲染共用.
+// 不追求完整的 CommonMark 兼容, 段落中的换行保留
+
+type BlockKind int
+
+const (
+	BlockParagraph BlockKind = iota
+	BlockBlank
+	BlockHeading
+	BlockRule
+	BlockQuote
+	BlockListItem
+	BlockCode
+	BlockTable
+)
+
+type Block struct {
+	Kind     BlockKind
+	Level    int      // 标题级别, 列表缩进层级
+	Lines    []string // 段落的各行, 标题和列表项只有一行
+	Marker   string   // 列表标记, 有序列表为 "1." 等
+	Task     int      // 0 不是待办, 1 未完成, 2 已完成
+	Lang     string   // 代码块语言
+	Code     string
+	Children []Block  // 引用中的内容
+	Header   []string // 表格
+	Aligns   []Align
+	Rows     [][]string
+}
+
+func (b Block) Ordered() bool {
+	return b.Marker != "" && b.Marker[0] >= '0' && b.Marker[0] <= '9'
+}
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+type Document struct {
+	FrontMatter []string // 包括首尾的 ---
+	Blocks      []Block
+}
+
+var (
+	headingRegex  = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+	fenceRegex    = regexp.MustCompile("^ {0,3}(```+|~~~+)\\s*([^\\s`]*)")
+	ruleRegex     = regexp.MustCompile(`^ {0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	quoteRegex    = regexp.MustCompile(`^ {0,3}>\s?`)
+	listRegex     = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskRegex     = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	separatorCell = regexp.MustCompile(`^\s*:?-+:?\s*$`)
+)
+
+func Parse(src []byte) Document {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimRight(string(src), "\n"), "\r\n", "\n"), "\n")
+	var doc Document
+	doc.FrontMatter, lines = frontMatter(lines)
+	doc.Blocks = parseBlocks(lines)
+	return doc
+}
+
+func frontMatter(lines []string) ([]string, []string) {
+	if len(lines) == 0 || lines[0] != "---" {
+		return nil, lines
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i] == "---" || lines[i] == "..." {
+			return lines[:i+1], lines[i+1:]
+		}
+	}
+	return nil, lines
+}
+
+func parseBlocks(lines []string) []Block {
+	var blocks []Block
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			blocks = append(blocks, Block{Kind: BlockBlank})
+		case fenceRegex.MatchString(line):
+			var b Block
+			b, i = parseFence(lines, i)
+			blocks = append(blocks, b)
+		case headingRegex.MatchString(line):
+			m := headingRegex.FindStringSubmatch(line)
+			blocks = append(blocks, Block{Kind: BlockHeading, Level: len(m[1]), Lines: []string{m[2]}})
+		case ruleRegex.MatchString(line):
+			blocks = append(blocks, Block{Kind: BlockRule})
+		case quoteRegex.MatchString(line):
+			var inner []string
+			for ; i < len(lines) && quoteRegex.MatchString(lines[i]); i++ {
+				inner = append(inner, quoteRegex.ReplaceAllString(lines[i], ""))
+			}
+			i--
+			blocks = append(blocks, Block{Kind: BlockQuote, Children: parseBlocks(inner)})
+		case i+1 < len(lines) && isTableRow(line) && isSeparator(lines[i+1]):
+			var b Block
+			b, i = parseTable(lines, i)
+			blocks = append(blocks, b)
+		case listRegex.MatchString(line):
+			blocks = append(blocks, parseListItem(line))
+		default:
+			// 连续的普通行合并为一个段落
+			if n := len(blocks); n > 0 && blocks[n-1].Kind == BlockParagraph {
+				blocks[n-1].Lines = append(blocks[n-1].Lines, line)
+			} else {
+				blocks = append(blocks, Block{Kind: BlockParagraph, Lines: []string{line}})
+			}
+		}
+	}
+	return blocks
+}
+
+// 代码块, 返回最后一行的位置
+func parseFence(lines []string, start int) (Block, int) {
+	m := fenceRegex.FindStringSubmatch(lines[start])
+	marker := m[1]
+	end := start + 1
+	for ; end < len(lines); end++ {
+		t := strings.TrimSpace(lines[end])
+		if strings.HasPrefix(t, marker) && strings.Trim(t, marker[:1]) == "" {
+			break
+		}
+	}
+	code := strings.Join(lines[start+1:min(end, len(lines))], "\n")
+	return Block{Kind: BlockCode, Lang: m[2], Code: code}, end
+}
+
+func parseListItem(line string) Block {
+	m := listRegex.FindStringSubmatch(line)
+	indent, text := strings.ReplaceAll(m[1], "\t", "    "), m[3]
+	b := Block{Kind: BlockListItem, Level: len(indent) / 2, Marker: m[2]}
+	if t := taskRegex.FindStringSubmatch(text); t != nil {
+		text = text[len(t[0]):]
+		b.Task = 1
+		if t[1] != " " {
+			b.Task = 2
+		}
+	}
+	b.Lines = []string{text}
+	return b
+}
+
+func isTableRow(line string) bool {
+	return strings.Contains(line, "|")
+}
+
+func isSeparator(line string) bool {
+	cells := splitRow(line)
+	if len(cells) == 0 {
+		return false
+	}
+	for _, c := range cells {
+		if !separatorCell.MatchString(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// 按未转义的 | 拆分单元格, 去掉首尾的 |
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var sb strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			sb.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			sb.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(sb.String()))
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(sb.String()))
+}
+
+func parseTable(lines []string, start int) (Block, int) {
+	b := Block{Kind: BlockTable, Header: splitRow(lines[start])}
+	for _, c := range splitRow(lines[start+1]) {
+		switch {
+		case strings.HasPrefix(c, ":") && strings.HasSuffix(c, ":"):
+			b.Aligns = append(b.Aligns, AlignCenter)
+		case strings.HasSuffix(c, ":"):
+			b.Aligns = append(b.Aligns, AlignRight)
+		default:
+			b.Aligns = append(b.Aligns, AlignLeft)
+		}
+	}
+	end := start + 2
+	for ; end < len(lines) && isTableRow(lines[end]) && strings.TrimSpace(lines[end]) != ""; end++ {
+		b.Rows = append(b.Rows, splitRow(lines[end]))
+	}
+	// 补齐列数
+	cols := len(b.Header)
+	for _, row := range b.Rows {
+		cols = max(cols, len(row))
+	}
+	b.Header = padRow(b.Header, cols)
+	for i := range b.Rows {
+		b.Rows[i] = padRow(b.Rows[i], cols)
+	}
+	for len(b.Aligns) < cols {
+		b.Aligns = append(b.Aligns, AlignLeft)
+	}
+	return b, end - 1
+}
+
+func padRow(row []string, n int) []string {
+	for len(row) < n {
+		row = append(row, "")
+	}
+	return row
+}
+
+type InlineKind int
+
+const (
+	InlineText InlineKind = iota
+	InlineCode
+	InlineStrong
+	InlineEmph
+	InlineStrike
+	InlineLink     // [Children](URL), 自动链接 <URL> 的 Children 为空
+	InlineImage    // ![Text](URL)
+	InlineWikiLink // [[URL|Text]], Text 可为空
+)
+
+type Inline struct {
+	Kind     InlineKind
+	Text     string
+	URL      string
+	Children []Inline
+}
+
+// 行内元素
+func ParseInline(s string) []Inline {
+	var nodes []Inline
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, Inline{Kind: InlineText, Text: text.String()})
+			text.Reset()
+		}
+	}
+	add := func(n Inline) {
+		flush()
+		nodes = append(nodes, n)
+	}
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_~[]()<>#|!", rune(rest[1])):
+			text.WriteByte(rest[1])
+			i += 2
+			continue
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if j := strings.Index(rest[ticks:], rest[:ticks]); j >= 0 {
+				add(Inline{Kind: InlineCode, Text: strings.TrimSpace(rest[ticks : ticks+j])})
+				i += ticks + j + ticks
+				continue
+			}
+			// 没有结束符时整串反引号原样输出
+			text.WriteString(rest[:ticks])
+			i += ticks
+			continue
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if inner, n, ok := delimited(s, i, rest[:2]); ok {
+				add(Inline{Kind: InlineStrong, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		case strings.HasPrefix(rest, "~~"):
+			if inner, n, ok := delimited(s, i, "~~"); ok {
+				add(Inline{Kind: InlineStrike, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		case rest[0] == '*' || rest[0] == '_':
+			if inner, n, ok := delimited(s, i, rest[:1]); ok {
+				add(Inline{Kind: InlineEmph, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		case strings.HasPrefix(rest, "[["):
+			if j := strings.Index(rest, "]]"); j > 2 {
+				target, alias := rest[2:j], ""
+				if bar := strings.Index(target, "|"); bar >= 0 {
+					target, alias = target[:bar], target[bar+1:]
+				}
+				add(Inline{Kind: InlineWikiLink, URL: strings.TrimSpace(target), Text: alias})
+				i += j + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "!["):
+			if text, url, n, ok := link(rest[1:]); ok {
+				add(Inline{Kind: InlineImage, Text: text, URL: url})
+				i += 1 + n
+				continue
+			}
+		case rest[0] == '[':
+			if text, url, n, ok := link(rest); ok {
+				add(Inline{Kind: InlineLink, URL: url, Children: ParseInline(text)})
+				i += n
+				continue
+			}
+		case rest[0] == '<':
+			if j := strings.IndexByte(rest, '>'); j > 0 {
+				if url := rest[1:j]; strings.Contains(url, "://") && !strings.ContainsAny(url, " \t") {
+					add(Inline{Kind: InlineLink, URL: url})
+					i += j + 1
+					continue
+				}
+			}
+		}
+		text.WriteByte(s[i])
+		i++
+	}
+	flush()
+	return nodes
+}
+
+// s[i:] 以 delim 开头时找到对应的结束符, 返回内部文本和总长度.
+// 和 CommonMark 一样, 开始符后面和结束符前面不能是空白, _ 只在单词边界生效
+func delimited(s string, i int, delim string) (string, int, bool) {
+	start := i + len(delim)
+	if start >= len(s) || s[start] == ' ' || s[start] == '\t' {
+		return "", 0, false
+	}
+	if delim[0] == '_' && i > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(s[:i]); isWord(r) {
+			return "", 0, false
+		}
+	}
+	for j := start + 1; j+len(delim) <= len(s); j++ {
+		if s[j-1] == '\\' || !strings.HasPrefix(s[j:], delim) {
+			continue
+		}
+		if s[j-1] == ' ' || s[j-1] == '\t' {
+			continue
+		}
+		// 单个 * 不能匹配到 ** 的一半
+		if len(delim) == 1 && j+1 < len(s) && s[j+1] == delim[0] {
+			j++
+			continue
+		}
+		after := j + len(delim)
+		if delim[0] == '_' && after < len(s) {
+			if r, _ := utf8.DecodeRuneInString(s[after:]); isWord(r) {
+				continue
+			}
+		}
+		return s[start:j], after - i, true
+	}
+	return "", 0, false
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// [text](url), 返回文本, 地址和总长度
+func link(s string) (string, string, int, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != '(' {
+				return "", "", 0, false
+			}
+			j := strings.IndexByte(s[i+2:], ')')
+			if j < 0 {
+				return "", "", 0, false
+			}
+			url := strings.TrimSpace(s[i+2 : i+2+j])
+			// 去掉链接标题 [text](url "title")
+			if k := strings.IndexAny(url, " \t"); k > 0 {
+				url = url[:k]
+			}
+			return s[1:i], strings.Trim(url, "<>"), i + 3 + j, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// 行内元素的纯文本, 用于标题锚点等
+func PlainText(nodes []Inline) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case InlineText, InlineCode, InlineImage:
+			sb.WriteString(n.Text)
+		case InlineWikiLink:
+			if n.Text != "" {
+				sb.WriteString(n.Text)
+			} else {
+				sb.WriteString(n.URL)
+			}
+		case InlineLink:
+			if len(n.Children) == 0 {
+				sb.WriteString(n.URL)
+			}
+			sb.WriteString(PlainText(n.Children))
+		default:
+			sb.WriteString(PlainText(n.Children))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,129 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		front []string
+		want  []Block
+	}{
+		{
+			name:  "front matter",
+			src:   "---\ntitle: a\n---\n# 标题 #\n",
+			front: []string{"---", "title: a", "---"},
+			want:  []Block{{Kind: BlockHeading, Level: 1, Lines: []string{"标题"}}},
+		},
+		{
+			name: "段落和空行",
+			src:  "第一行\n第二行\r\n\n第三行\n",
+			want: []Block{
+				{Kind: BlockParagraph, Lines: []string{"第一行", "第二行"}},
+				{Kind: BlockBlank},
+				{Kind: BlockParagraph, Lines: []string{"第三行"}},
+			},
+		},
+		{
+			name: "代码块",
+			src:  "```go\nfunc main() {}\n\n# 不是标题\n```\n***",
+			want: []Block{
+				{Kind: BlockCode, Lang: "go", Code: "func main() {}\n\n# 不是标题"},
+				{Kind: BlockRule},
+			},
+		},
+		{
+			name: "未闭合的代码块",
+			src:  "~~~\na\nb",
+			want: []Block{{Kind: BlockCode, Code: "a\nb"}},
+		},
+		{
+			name: "列表和待办",
+			src:  "- a\n  * [ ] b\n1. [x] c\n\t- d",
+			want: []Block{
+				{Kind: BlockListItem, Marker: "-", Lines: []string{"a"}},
+				{Kind: BlockListItem, Level: 1, Marker: "*", Task: 1, Lines: []string{"b"}},
+				{Kind: BlockListItem, Marker: "1.", Task: 2, Lines: []string{"c"}},
+				{Kind: BlockListItem, Level: 2, Marker: "-", Lines: []string{"d"}},
+			},
+		},
+		{
+			name: "引用",
+			src:  "> ## 小标题\n> 内容\n正文",
+			want: []Block{
+				{Kind: BlockQuote, Children: []Block{
+					{Kind: BlockHeading, Level: 2, Lines: []string{"小标题"}},
+					{Kind: BlockParagraph, Lines: []string{"内容"}},
+				}},
+				{Kind: BlockParagraph, Lines: []string{"正文"}},
+			},
+		},
+		{
+			name: "表格",
+			src:  "| 名称 | 数量 | 备注 |\n|:--|--:|:-:|\n| a \\| b | `x|y` |\n| c | 2 | 3 | 4 |\n\n尾",
+			want: []Block{
+				{
+					Kind:   BlockTable,
+					Header: []string{"名称", "数量", "备注", ""},
+					Aligns: []Align{AlignLeft, AlignRight, AlignCenter, AlignLeft},
+					Rows: [][]string{
+						{"a | b", "`x|y`", "", ""},
+						{"c", "2", "3", "4"},
+					},
+				},
+				{Kind: BlockBlank},
+				{Kind: BlockParagraph, Lines: []string{"尾"}},
+			},
+		},
+		{
+			name: "不是表格",
+			src:  "a | b\nc | d",
+			want: []Block{{Kind: BlockParagraph, Lines: []string{"a | b", "c | d"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Parse([]byte(tt.src))
+			if !reflect.DeepEqual(doc.FrontMatter, tt.front) {
+				t.Errorf("front matter = %q, want %q", doc.FrontMatter, tt.front)
+			}
+			if !reflect.DeepEqual(doc.Blocks, tt.want) {
+				t.Errorf("blocks = %+v\nwant %+v", doc.Blocks, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	text := func(s string) Inline { return Inline{Kind: InlineText, Text: s} }
+	tests := []struct {
+		src  string
+		want []Inline
+	}{
+		{src: "纯文本", want: []Inline{text("纯文本")}},
+		{src: "a `b*c` d", want: []Inline{text("a "), {Kind: InlineCode, Text: "b*c"}, text(" d")}},
+		{src: "``没有结束", want: []Inline{text("``没有结束")}},
+		{src: "**粗 *斜* 体**", want: []Inline{{Kind: InlineStrong, Children: []Inline{
+			text("粗 "), {Kind: InlineEmph, Children: []Inline{text("斜")}}, text(" 体"),
+		}}}},
+		{src: "~~删除~~", want: []Inline{{Kind: InlineStrike, Children: []Inline{text("删除")}}}},
+		{src: "snake_case_name", want: []Inline{text("snake_case_name")}},
+		{src: "2 * 3 * 4", want: []Inline{text("2 * 3 * 4")}},
+		{src: `\*转义\*`, want: []Inline{text("*转义*")}},
+		{src: "[文档](docs/a.md \"标题\")", want: []Inline{{Kind: InlineLink, URL: "docs/a.md", Children: []Inline{text("文档")}}}},
+		{src: "![图](<img.png>)", want: []Inline{{Kind: InlineImage, Text: "图", URL: "img.png"}}},
+		{src: "[[周会#议程|会议]] [[a]]", want: []Inline{
+			{Kind: InlineWikiLink, URL: "周会#议程", Text: "会议"}, text(" "), {Kind: InlineWikiLink, URL: "a"},
+		}},
+		{src: "<https://example.com> <b>", want: []Inline{{Kind: InlineLink, URL: "https://example.com"}, text(" <b>")}},
+		{src: "[没有地址]", want: []Inline{text("[没有地址]")}},
+	}
+	for _, tt := range tests {
+		if got := ParseInline(tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseInline(%q) = %+v\nwant %+v", tt.src, got, tt.want)
+		}
+	}
+}
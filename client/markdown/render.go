@@ -0,0 +1,241 @@
+package markdown
+
+import (
+	"bytes"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/quick"
+	"note/client/tui"
+	"note/shell"
+	"strings"
+)
+
+// 终端渲染: 标题, 列表, 待办, 引用, 表格, 分隔线, 强调, 链接, 代码块按语言用 chroma 着色
+
+const DefaultStyle = "monokai"
+
+type Options struct {
+	Width int    // 分隔线和标题下划线的宽度, 0 时为 80
+	Style string // 代码块的 chroma 样式, 为空时用 DefaultStyle
+}
+
+var bullets = []string{"•", "◦", "▪"}
+
+type renderer struct {
+	opt Options
+	out []string
+}
+
+func Render(src []byte, opt Options) string {
+	if opt.Width <= 0 {
+		opt.Width = 80
+	}
+	if opt.Style == "" {
+		opt.Style = DefaultStyle
+	}
+	doc := Parse(src)
+	r := &renderer{opt: opt}
+	// front matter 变暗显示
+	for _, l := range doc.FrontMatter {
+		r.out = append(r.out, shell.BrightBlack+l+shell.ResetAll)
+	}
+	r.blocks(doc.Blocks)
+	return strings.Join(r.out, "\n") + "\n"
+}
+
+func (r *renderer) blocks(blocks []Block) {
+	for _, b := range blocks {
+		switch b.Kind {
+		case BlockBlank:
+			r.out = append(r.out, "")
+		case BlockParagraph:
+			for _, l := range b.Lines {
+				r.out = append(r.out, inline(l, ""))
+			}
+		case BlockCode:
+			r.code(b)
+		case BlockHeading:
+			r.heading(b)
+		case BlockRule:
+			r.out = append(r.out, shell.BrightBlack+strings.Repeat("─", r.opt.Width)+shell.ResetAll)
+		case BlockQuote:
+			r.quote(b)
+		case BlockTable:
+			r.table(b)
+		case BlockListItem:
+			r.listItem(b)
+		}
+	}
+}
+
+func (r *renderer) code(b Block) {
+	label := ""
+	if b.Lang != "" {
+		label = " " + b.Lang
+	}
+	r.out = append(r.out, shell.BrightBlack+"┌"+label+shell.ResetAll)
+	for _, l := range tui.SplitLines(Highlight(b.Code, b.Lang, r.opt.Style)) {
+		r.out = append(r.out, shell.BrightBlack+"│ "+shell.ResetAll+l+shell.ResetAll)
+	}
+	r.out = append(r.out, shell.BrightBlack+"└"+shell.ResetAll)
+}
+
+func (r *renderer) heading(b Block) {
+	var style string
+	switch b.Level {
+	case 1:
+		style = shell.Bold + shell.BrightMagenta
+	case 2:
+		style = shell.Bold + shell.BrightCyan
+	case 3:
+		style = shell.Bold + shell.BrightYellow
+	default:
+		style = shell.Bold + shell.BrightGreen
+	}
+	rendered := style + inline(b.Lines[0], style) + shell.ResetAll
+	r.out = append(r.out, rendered)
+	width := max(1, min(r.opt.Width, tui.Width(rendered)))
+	switch b.Level {
+	case 1:
+		r.out = append(r.out, shell.BrightMagenta+strings.Repeat("═", width)+shell.ResetAll)
+	case 2:
+		r.out = append(r.out, shell.BrightCyan+strings.Repeat("─", width)+shell.ResetAll)
+	}
+}
+
+// 引用中的内容递归渲染, 支持嵌套
+func (r *renderer) quote(b Block) {
+	sub := &renderer{opt: r.opt}
+	sub.opt.Width = max(1, r.opt.Width-2)
+	sub.blocks(b.Children)
+	for _, l := range sub.out {
+		r.out = append(r.out, shell.BrightBlack+"│ "+shell.ResetAll+shell.Italic+strings.ReplaceAll(l, shell.ResetAll, shell.ResetAll+shell.Italic)+shell.ResetAll)
+	}
+}
+
+func (r *renderer) listItem(b Block) {
+	indent := strings.Repeat("  ", b.Level)
+	text := b.Lines[0]
+	switch {
+	case b.Task == 1:
+		r.out = append(r.out, indent+shell.BrightYellow+"☐"+shell.ResetAll+" "+inline(text, ""))
+	case b.Task == 2:
+		style := shell.BrightBlack + shell.Strike
+		r.out = append(r.out, indent+shell.BrightGreen+"☑"+shell.ResetAll+" "+style+inline(text, style)+shell.ResetAll)
+	case b.Ordered():
+		r.out = append(r.out, indent+shell.BrightYellow+b.Marker+shell.ResetAll+" "+inline(text, ""))
+	default:
+		r.out = append(r.out, indent+shell.BrightYellow+bullets[b.Level%len(bullets)]+shell.ResetAll+" "+inline(text, ""))
+	}
+}
+
+func (r *renderer) table(b Block) {
+	cols := len(b.Header)
+	widths := make([]int, cols)
+	cells := make([][]string, 0, len(b.Rows)+1)
+	for i, row := range append([][]string{b.Header}, b.Rows...) {
+		rendered := make([]string, cols)
+		for j, text := range row {
+			if i == 0 {
+				rendered[j] = shell.Bold + inline(text, shell.Bold) + shell.ResetAll
+			} else {
+				rendered[j] = inline(text, "")
+			}
+			widths[j] = max(widths[j], tui.Width(rendered[j]))
+		}
+		cells = append(cells, rendered)
+	}
+
+	border := func(left, mid, right string) string {
+		parts := make([]string, cols)
+		for j, w := range widths {
+			parts[j] = strings.Repeat("─", w+2)
+		}
+		return shell.BrightBlack + left + strings.Join(parts, mid) + right + shell.ResetAll
+	}
+	bar := shell.BrightBlack + "│" + shell.ResetAll
+	r.out = append(r.out, border("┌", "┬", "┐"))
+	for i, row := range cells {
+		var sb strings.Builder
+		sb.WriteString(bar)
+		for j, cell := range row {
+			sb.WriteString(" " + alignCell(cell, widths[j], b.Aligns[j]) + " " + bar)
+		}
+		r.out = append(r.out, sb.String())
+		if i == 0 {
+			r.out = append(r.out, border("├", "┼", "┤"))
+		}
+	}
+	r.out = append(r.out, border("└", "┴", "┘"))
+}
+
+func alignCell(s string, w int, a Align) string {
+	pad := w - tui.Width(s)
+	switch a {
+	case AlignRight:
+		return strings.Repeat(" ", pad) + s
+	case AlignCenter:
+		return strings.Repeat(" ", pad/2) + s + strings.Repeat(" ", pad-pad/2)
+	}
+	return s + strings.Repeat(" ", pad)
+}
+
+// 行内元素, base 是外层样式, 每个样式结束后重新应用
+func inline(s, base string) string {
+	return renderInline(ParseInline(s), base)
+}
+
+func renderInline(nodes []Inline, base string) string {
+	var sb strings.Builder
+	end := shell.ResetAll + base
+	styled := func(style string, children []Inline) {
+		sb.WriteString(style + renderInline(children, base+style) + end)
+	}
+	for _, n := range nodes {
+		switch n.Kind {
+		case InlineText:
+			sb.WriteString(n.Text)
+		case InlineCode:
+			sb.WriteString(shell.BrightCyan + n.Text + end)
+		case InlineStrong:
+			styled(shell.Bold, n.Children)
+		case InlineEmph:
+			styled(shell.Italic, n.Children)
+		case InlineStrike:
+			styled(shell.Strike, n.Children)
+		case InlineWikiLink:
+			label := n.URL
+			if n.Text != "" {
+				label += "|" + n.Text
+			}
+			sb.WriteString(shell.BrightCyan + "[[" + label + "]]" + end)
+		case InlineImage:
+			sb.WriteString(shell.BrightBlack + "[图片: " + end + n.Text + shell.BrightBlack + "] " + n.URL + end)
+		case InlineLink:
+			if len(n.Children) == 0 {
+				sb.WriteString(shell.Underline + shell.BrightBlue + n.URL + end)
+				continue
+			}
+			styled(shell.Underline+shell.BrightBlue, n.Children)
+			if n.URL != PlainText(n.Children) {
+				sb.WriteString(shell.BrightBlack + " (" + n.URL + ")" + end)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// 用 chroma 给代码着色, lang 为空或无法识别时按内容猜测, 仍无法识别时原样返回
+func Highlight(code, lang, style string) string {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		return code
+	}
+	var buf bytes.Buffer
+	if err := quick.Highlight(&buf, code, lexer.Config().Name, "terminal256", style); err != nil {
+		return code
+	}
+	return buf.String()
+}
@@ -11,11 +11,23 @@ import (
 
 func Exec() {
 	// 创建MCP服务器
-	s := server.NewMCPServer("Excel Assistant", "1.0.0",
+	hooks := &server.Hooks{}
+	s := server.NewMCPServer("Note Assistant", "1.0.0",
 		server.WithResourceCapabilities(true, true),
 		server.WithLogging(),
+		server.WithHooks(hooks),
 	)
 
+	registerExcel(s)
+	registerNote(s, hooks)
+
+	// 启动服务器
+	if err := server.ServeStdio(s); err != nil {
+		fmt.Printf("Server error: %v\n", err)
+	}
+}
+
+func registerExcel(s *server.MCPServer) {
 	// 注册Excel读取资源模板
 	excelResource := mcp.NewResourceTemplate(
 		"excel://{file}/sheet/{sheet}/range/{range}",
@@ -71,11 +83,6 @@ func Exec() {
 		)
 		s.AddTool(t, tool.handler)
 	}
-
-	// 启动服务器
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Printf("Server error: %v\n", err)
-	}
 }
 
 // Excel资源处理器
@@ -0,0 +1,278 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"mime"
+	"note/client/lib"
+	"note/shell"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 笔记资源前缀, 形如 note://java/a.go
+const noteScheme = "note://"
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*[mK]`)
+
+// 常见笔记扩展名, mime 包默认不认识的补充在这里
+var noteMIMETypes = map[string]string{
+	".md":   "text/markdown",
+	".txt":  "text/plain",
+	".go":   "text/x-go",
+	".java": "text/x-java",
+	".py":   "text/x-python",
+	".sh":   "text/x-shellscript",
+	".sql":  "application/sql",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".json": "application/json",
+}
+
+func registerNote(s *server.MCPServer, hooks *server.Hooks) {
+	// 笔记资源在每次列出时从存储目录读取, 移动/删除后不会留下失效的资源, 读取统一通过模板
+	hooks.AddAfterListResources(func(ctx context.Context, id any, req *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+		result.Resources = append(result.Resources, noteResources()...)
+	})
+	noteResource := mcp.NewResourceTemplate(
+		noteScheme+"{+path}",
+		"Note",
+		mcp.WithTemplateDescription("Read a note from the note store by relative path"),
+	)
+	s.AddResourceTemplate(noteResource, noteResourceHandler)
+
+	// 注册笔记工具
+	s.AddTool(mcp.NewTool("list_notes",
+		mcp.WithDescription("List the note store as a numbered tree with note ids, both numbers and ids can be used as note path"),
+	), listNotesHandler)
+
+	s.AddTool(mcp.NewTool("view_note",
+		mcp.WithDescription("Read the content of a note"),
+		mcp.WithString("path", mcp.Required(),
+			mcp.Description("Relative note path, tree index like 3.2 or note id like @k3x9")),
+	), viewNoteHandler)
+
+	s.AddTool(mcp.NewTool("search_notes",
+		mcp.WithDescription("Search all notes for a keyword, each hit is prefixed with its tree index"),
+		mcp.WithString("keyword", mcp.Required(),
+			mcp.Description("Keyword to search for")),
+	), searchNotesHandler)
+
+	s.AddTool(mcp.NewTool("write_note",
+		mcp.WithDescription("Create or overwrite a note and commit it"),
+		mcp.WithString("path", mcp.Required(),
+			mcp.Description("Relative note path, tree index like 3.2 or note id like @k3x9")),
+		mcp.WithString("content", mcp.Required(),
+			mcp.Description("Full content of the note")),
+	), writeNoteHandler(s))
+
+	s.AddTool(mcp.NewTool("move_note",
+		mcp.WithDescription("Move or rename a note or directory and commit it"),
+		mcp.WithString("from", mcp.Required(),
+			mcp.Description("Relative source path, tree index or note id")),
+		mcp.WithString("to", mcp.Required(),
+			mcp.Description("Relative target path")),
+	), moveNoteHandler(s))
+
+	s.AddTool(mcp.NewTool("remove_note",
+		mcp.WithDescription("Move a note or directory to the trash and commit it"),
+		mcp.WithString("path", mcp.Required(),
+			mcp.Description("Relative note path, tree index or note id")),
+	), removeNoteHandler(s))
+}
+
+// 存储目录下的所有笔记
+func noteResources() []mcp.Resource {
+	var resources []mcp.Resource
+	filepath.WalkDir(lib.StorePath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		// 跳过 .git, .note 等隐藏文件
+		if strings.HasPrefix(d.Name(), ".") && path != lib.StorePath {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel := lib.RelPath(path)
+		resources = append(resources, mcp.NewResource(noteScheme+rel, rel,
+			mcp.WithMIMEType(noteMIMEType(rel)),
+		))
+		return nil
+	})
+	return resources
+}
+
+// 通知客户端笔记列表已变化, 客户端不支持时忽略
+func notifyResourcesChanged(ctx context.Context, s *server.MCPServer) {
+	s.SendNotificationToClient(ctx, "notifications/resources/list_changed", nil)
+}
+
+func noteMIMEType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return "text/plain"
+	}
+	if t, ok := noteMIMETypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func isTextMIMEType(t string) bool {
+	return strings.HasPrefix(t, "text/") ||
+		strings.HasPrefix(t, "application/json") ||
+		strings.HasPrefix(t, "application/yaml") ||
+		strings.HasPrefix(t, "application/sql") ||
+		strings.HasPrefix(t, "application/xml")
+}
+
+// 笔记资源处理器
+func noteResourceHandler(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	rel := strings.TrimPrefix(req.Params.URI, noteScheme)
+	path, err := notePath(rel)
+	if err != nil {
+		return nil, err
+	}
+	data, err := lib.ReadNote(lib.RelPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := noteMIMEType(path)
+	if isTextMIMEType(mimeType) {
+		return []mcp.ResourceContents{
+			&mcp.TextResourceContents{
+				URI:      req.Params.URI,
+				MIMEType: mimeType,
+				Text:     string(data),
+			},
+		}, nil
+	}
+	return []mcp.ResourceContents{
+		&mcp.BlobResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: mimeType,
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		},
+	}, nil
+}
+
+func listNotesHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText(shell.TreeString(lib.StorePath, lib.TreeOptions(""))), nil
+}
+
+func viewNoteHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := notePath(stringArg(req, "path"))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	data, err := lib.ReadNote(lib.RelPath(path))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to read note", err), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func searchNotesHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	keyword := stringArg(req, "keyword")
+	if keyword == "" {
+		return mcp.NewToolResultError("keyword is required"), nil
+	}
+	var sb strings.Builder
+	for _, line := range lib.SearchLines(keyword) {
+		line = strings.TrimSpace(ansiRegex.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		sb.WriteString(line + "\n")
+	}
+	if sb.Len() == 0 {
+		return mcp.NewToolResultText("No match"), nil
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func writeNoteHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := notePath(stringArg(req, "path"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		rel := lib.RelPath(path)
+		if err := lib.WriteNote(rel, stringArg(req, "content")); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to write note", err), nil
+		}
+		notifyResourcesChanged(ctx, s)
+		return mcp.NewToolResultText("Note written: " + rel), nil
+	}
+}
+
+func moveNoteHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		from, err := notePath(stringArg(req, "from"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		to, err := notePath(stringArg(req, "to"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := lib.MoveNoteRel(lib.RelPath(from), lib.RelPath(to)); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to move note", err), nil
+		}
+		notifyResourcesChanged(ctx, s)
+		return mcp.NewToolResultText(fmt.Sprintf("Note moved: %s -> %s", lib.RelPath(from), lib.RelPath(to))), nil
+	}
+}
+
+func removeNoteHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := notePath(stringArg(req, "path"))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		item, err := lib.DeleteNoteRel(lib.RelPath(path))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to remove note", err), nil
+		}
+		notifyResourcesChanged(ctx, s)
+		return mcp.NewToolResultText(fmt.Sprintf("Note moved to trash: %s (restore with note trash restore %s)", item.Path, item.ID)), nil
+	}
+}
+
+// 解析笔记路径, 不允许访问存储目录之外以及 .git 下的文件
+func notePath(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	path := lib.ResolvePath(name)
+	if path == "" {
+		return "", fmt.Errorf("note not found: %s", name)
+	}
+	rel := lib.RelPath(path)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("path outside note store: %s", name)
+	}
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") || strings.HasPrefix(rel, ".note/") {
+		return "", fmt.Errorf("path not allowed: %s", name)
+	}
+	return path, nil
+}
+
+func stringArg(req mcp.CallToolRequest, name string) string {
+	v, _ := req.Params.Arguments[name].(string)
+	return v
+}
@@ -0,0 +1,196 @@
+package meta
+
+import (
+	"crypto/rand"
+	"errors"
+	"gopkg.in/yaml.v2"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 笔记的固定 ID, 保存在存储目录的 .note/ids.yaml 中并随仓库提交,
+// 重命名/移动后 ID 不变, 不像目录树下标那样随排序变化
+
+const IDFile = ".note/ids.yaml"
+
+const (
+	idLength  = 4
+	idLetters = "abcdefghijklmnopqrstuvwxyz"
+	idChars   = idLetters + "0123456789"
+)
+
+type IDs struct {
+	root   string
+	byID   map[string]string // ID -> 相对路径
+	byPath map[string]string // 相对路径 -> ID
+	dirty  bool
+}
+
+func LoadIDs(root string) (*IDs, error) {
+	x := &IDs{
+		root:   root,
+		byID:   make(map[string]string),
+		byPath: make(map[string]string),
+	}
+	data, err := os.ReadFile(filepath.Join(root, IDFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]string
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for id, rel := range m {
+		x.byID[id] = rel
+		x.byPath[rel] = id
+	}
+	return x, nil
+}
+
+func (x *IDs) Save() error {
+	if !x.dirty {
+		return nil
+	}
+	data, err := yaml.Marshal(x.byID)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(x.root, IDFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	x.dirty = false
+	return nil
+}
+
+// 是否有未保存的改动
+func (x *IDs) Dirty() bool {
+	return x.dirty
+}
+
+func (x *IDs) Path(id string) (string, bool) {
+	rel, ok := x.byID[strings.ToLower(id)]
+	return rel, ok
+}
+
+func (x *IDs) ID(rel string) string {
+	return x.byPath[clean(rel)]
+}
+
+// 返回笔记的 ID, 没有则分配一个新的
+func (x *IDs) Assign(rel string) string {
+	rel = clean(rel)
+	if id, ok := x.byPath[rel]; ok {
+		return id
+	}
+	id := x.newID()
+	x.byID[id] = rel
+	x.byPath[rel] = id
+	x.dirty = true
+	return id
+}
+
+// 文件或目录移动后更新路径
+func (x *IDs) Move(from, to string) {
+	from, to = clean(from), clean(to)
+	for rel, id := range x.byPath {
+		var target string
+		switch {
+		case rel == from:
+			target = to
+		case strings.HasPrefix(rel, from+"/"):
+			target = to + strings.TrimPrefix(rel, from)
+		default:
+			continue
+		}
+		delete(x.byPath, rel)
+		x.byPath[target] = id
+		x.byID[id] = target
+		x.dirty = true
+	}
+}
+
+// 删除文件或目录下所有笔记的 ID
+func (x *IDs) Remove(rel string) {
+	rel = clean(rel)
+	for p, id := range x.byPath {
+		if p == rel || strings.HasPrefix(p, rel+"/") {
+			delete(x.byPath, p)
+			delete(x.byID, id)
+			x.dirty = true
+		}
+	}
+}
+
+// 文件或目录下所有笔记的 ID, 相对路径 -> ID
+func (x *IDs) Under(rel string) map[string]string {
+	rel = clean(rel)
+	m := make(map[string]string)
+	for p, id := range x.byPath {
+		if p == rel || strings.HasPrefix(p, rel+"/") {
+			m[p] = id
+		}
+	}
+	return m
+}
+
+// 恢复笔记原来的 ID, 已被其它笔记占用时分配新的
+func (x *IDs) Set(rel, id string) string {
+	rel = clean(rel)
+	if other, ok := x.byID[id]; ok && other != rel {
+		return x.Assign(rel)
+	}
+	if old, ok := x.byPath[rel]; ok {
+		delete(x.byID, old)
+	}
+	x.byID[id] = rel
+	x.byPath[rel] = id
+	x.dirty = true
+	return id
+}
+
+// 清理已不存在的文件
+func (x *IDs) Prune() {
+	for rel, id := range x.byPath {
+		if _, err := os.Stat(filepath.Join(x.root, rel)); errors.Is(err, os.ErrNotExist) {
+			delete(x.byPath, rel)
+			delete(x.byID, id)
+			x.dirty = true
+		}
+	}
+}
+
+// 生成随机 ID, 至少包含一个字母, 避免和纯数字的目录树下标混淆
+func (x *IDs) newID() string {
+	for {
+		b := make([]byte, idLength)
+		for i := range b {
+			b[i] = idChars[randInt(len(idChars))]
+		}
+		b[randInt(idLength)] = idLetters[randInt(len(idLetters))]
+		id := string(b)
+		if _, exists := x.byID[id]; !exists {
+			return id
+		}
+	}
+}
+
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
+
+func clean(rel string) string {
+	return filepath.ToSlash(filepath.Clean(rel))
+}
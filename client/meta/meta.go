@@ -0,0 +1,205 @@
+package meta
+
+import (
+	"bytes"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 笔记头部的 YAML front matter, 形如:
+//
+//	---
+//	title: 周会纪要
+//	tags: [work, meeting]
+//	created: 2026-10-17 09:30
+//	updated: 2026-10-17 10:00
+//	---
+
+const TimeLayout = "2006-01-02 15:04"
+
+const delimiter = "---"
+
+type Meta struct {
+	Title   string                 `yaml:"title,omitempty"`
+	Tags    []string               `yaml:"tags"`
+	Created string                 `yaml:"created,omitempty"`
+	Updated string                 `yaml:"updated,omitempty"`
+	Extra   map[string]interface{} `yaml:",inline"` // 其他自定义字段, 原样保留
+}
+
+// 创建新笔记的默认元数据, 标题取文件名
+func New(path string) *Meta {
+	now := time.Now().Format(TimeLayout)
+	name := filepath.Base(path)
+	return &Meta{
+		Title:   strings.TrimSuffix(name, filepath.Ext(name)),
+		Tags:    []string{},
+		Created: now,
+		Updated: now,
+	}
+}
+
+// 只有文本类笔记才写入 front matter, 代码文件保持原样
+func Supports(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case "", ".md", ".markdown", ".txt":
+		return true
+	}
+	return false
+}
+
+// 解析 front matter, 没有 front matter 时返回 nil 和原始内容
+func Parse(content []byte) (*Meta, []byte, error) {
+	head, body, ok := split(content)
+	if !ok {
+		return nil, content, nil
+	}
+	m := &Meta{}
+	if err := yaml.Unmarshal(head, m); err != nil {
+		return nil, content, err
+	}
+	return m, body, nil
+}
+
+func ParseFile(path string) (*Meta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, _, err := Parse(data)
+	return m, err
+}
+
+// 将元数据和正文重新拼接成笔记内容
+func Render(m *Meta, body []byte) ([]byte, error) {
+	if m == nil {
+		return body, nil
+	}
+	if m.Tags == nil {
+		m.Tags = []string{}
+	}
+	head, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+	buf.Write(head)
+	buf.WriteString(delimiter + "\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// 刷新文件的 updated 字段, 没有 front matter 的文件不做处理.
+// 只改写 updated 这一行, 注释, 字段顺序等其它内容原样保留
+func Touch(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m, _, err := Parse(data)
+	if err != nil || m == nil {
+		return err
+	}
+	start, end, _ := headRange(data)
+	line := "updated: " + time.Now().Format(TimeLayout)
+	head := string(data[start:end])
+	lines := strings.SplitAfter(head, "\n")
+	found := false
+	for i, l := range lines {
+		if strings.HasPrefix(l, "updated:") {
+			eol := l[len(strings.TrimRight(l, "\r\n")):]
+			lines[i] = line + eol
+			found = true
+			break
+		}
+	}
+	if !found {
+		if head != "" && !strings.HasSuffix(head, "\n") {
+			line = "\n" + line
+		}
+		lines = append(lines, line+"\n")
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:start])
+	buf.WriteString(strings.Join(lines, ""))
+	buf.Write(data[end:])
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// 是否包含某个标签, 不区分大小写
+func (m *Meta) HasTag(tag string) bool {
+	if m == nil {
+		return false
+	}
+	for _, t := range m.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// 笔记标题: front matter 中的 title, 没有时取第一个 # 标题, 都没有时为空
+func Title(content []byte) string {
+	m, body, err := Parse(content)
+	if err == nil && m != nil && m.Title != "" {
+		return m.Title
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		// # 后面要有空格, #tag 不算标题
+		if rest := strings.TrimLeft(line, "#"); rest != line && strings.HasPrefix(rest, " ") {
+			if title := strings.TrimSpace(rest); title != "" {
+				return title
+			}
+		}
+	}
+	return ""
+}
+
+func split(content []byte) (head, body []byte, ok bool) {
+	start, end, ok := headRange(content)
+	if !ok {
+		return nil, content, false
+	}
+	// 跳过结束的 ---
+	rest := content[end:]
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[i+1:]
+	} else {
+		rest = nil
+	}
+	return content[start:end], rest, true
+}
+
+// front matter 内容在 content 中的起止位置, 不含前后的 --- 行
+func headRange(content []byte) (start, end int, ok bool) {
+	text := string(content)
+	if strings.HasPrefix(text, "\ufeff") {
+		start = len("\ufeff")
+	}
+	if !strings.HasPrefix(text[start:], delimiter+"\n") && !strings.HasPrefix(text[start:], delimiter+"\r\n") {
+		return 0, 0, false
+	}
+	start += strings.Index(text[start:], "\n") + 1
+	for offset := start; offset < len(text); {
+		end := strings.Index(text[offset:], "\n")
+		var line string
+		if end == -1 {
+			line = text[offset:]
+			end = len(text) - offset
+		} else {
+			line = text[offset : offset+end]
+			end++
+		}
+		if strings.TrimRight(line, "\r") == delimiter {
+			return start, offset, true
+		}
+		offset += end
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,68 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 只改写 updated 这一行, 注释和字段顺序保持不变
+func TestTouch(t *testing.T) {
+	now := "updated: " + time.Now().Format(TimeLayout)
+	tests := []struct {
+		name    string
+		content string
+		want    string // {now} 替换为当前时间的 updated 行
+	}{
+		{
+			name:    "改写",
+			content: "---\n# 周会\ncreated: 2026-10-17 09:30\nupdated: 2026-10-17 10:00\ntitle: 周会\n---\n正文\n",
+			want:    "---\n# 周会\ncreated: 2026-10-17 09:30\n{now}\ntitle: 周会\n---\n正文\n",
+		},
+		{
+			name:    "CRLF",
+			content: "---\r\ntitle: 周会\r\nupdated: 2026-10-17 10:00\r\n---\r\n正文\r\n",
+			want:    "---\r\ntitle: 周会\r\n{now}\r\n---\r\n正文\r\n",
+		},
+		{
+			name:    "没有 updated",
+			content: "\ufeff---\ntitle: 周会\n---\n正文\n",
+			want:    "\ufeff---\ntitle: 周会\n{now}\n---\n正文\n",
+		},
+		{
+			name:    "没有 front matter",
+			content: "# 周会\n---\n正文\n",
+			want:    "# 周会\n---\n正文\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := Touch(path); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.ReplaceAll(tt.want, "{now}", now); string(got) != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	m, body, err := Parse([]byte("---\ntitle: 周会\ntags: [work]\n---\n正文\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Title != "周会" || !m.HasTag("WORK") || string(body) != "正文\n" {
+		t.Errorf("Parse = %+v, %q", m, body)
+	}
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// 桌面通知, Linux 下优先 notify-send, 没有时通过 gdbus 直接调用
+// org.freedesktop.Notifications, macOS 下使用 osascript 并播放提示音
+type Desktop struct{}
+
+// 当前环境是否可以发送桌面通知
+func DesktopAvailable() bool {
+	if runtime.GOOS == "darwin" {
+		return lookPath("osascript")
+	}
+	return lookPath("notify-send") || lookPath("gdbus")
+}
+
+func (Desktop) Notify(m Message) error {
+	if runtime.GOOS == "darwin" {
+		exec.Command("afplay", "/System/Library/Sounds/Ping.aiff").Start()
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(m.Body), strconv.Quote(m.Title))
+		return run(exec.Command("osascript", "-e", script))
+	}
+	if lookPath("notify-send") {
+		return run(exec.Command("notify-send", "--app-name=note", "--urgency=critical", m.Title, m.Body))
+	}
+	if lookPath("gdbus") {
+		return run(exec.Command("gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			"note", "0", "", m.Title, m.Body, "[]", "{}", "-1"))
+	}
+	return errors.New("桌面通知不可用, 需要 notify-send 或 gdbus")
+}
+
+func lookPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v %s", cmd.Path, err, out)
+	}
+	return nil
+}
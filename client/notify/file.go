@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 追加写入日志文件, 每条一行
+type File struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *File) Notify(m Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "%s %s %s\n", m.Time.Format("2006-01-02 15:04:05"), m.Title, m.Body)
+	return err
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// 提醒的通知方式, 可同时使用多种, 见 New
+
+type Message struct {
+	Title string
+	Body  string
+	Time  time.Time
+}
+
+type Notifier interface {
+	Notify(m Message) error
+}
+
+// 通知方式名称
+const (
+	NameDesktop = "desktop" // freedesktop 桌面通知(D-Bus), macOS 使用 osascript
+	NameWebhook = "webhook" // POST JSON 到 webhook 地址
+	NameFile    = "file"    // 追加写入日志文件
+	NameStdout  = "stdout"  // 输出到标准输出并响铃
+)
+
+var Names = []string{NameDesktop, NameWebhook, NameFile, NameStdout}
+
+type Options struct {
+	Webhook string    // webhook 地址
+	File    string    // 日志文件路径
+	Out     io.Writer // stdout 方式的输出, 默认 os.Stdout
+}
+
+// 按名称创建通知方式, 多个时依次通知
+func New(names []string, opt Options) (Notifier, error) {
+	var ns Multi
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case NameDesktop:
+			ns = append(ns, Desktop{})
+		case NameWebhook:
+			if opt.Webhook == "" {
+				return nil, errors.New("未配置 notify.webhook 地址")
+			}
+			ns = append(ns, &Webhook{URL: opt.Webhook})
+		case NameFile:
+			if opt.File == "" {
+				return nil, errors.New("未配置 notify.file 路径")
+			}
+			ns = append(ns, &File{Path: opt.File})
+		case NameStdout:
+			out := opt.Out
+			if out == nil {
+				out = os.Stdout
+			}
+			ns = append(ns, &Stdout{W: out})
+		case "":
+		default:
+			return nil, fmt.Errorf("不支持的通知方式: %s, 可选 %s", name, strings.Join(Names, "/"))
+		}
+	}
+	if len(ns) == 1 {
+		return ns[0], nil
+	}
+	return ns, nil
+}
+
+// 依次使用每种方式通知, 某一种失败不影响其它
+type Multi []Notifier
+
+func (ns Multi) Notify(m Message) error {
+	var errs []error
+	for _, n := range ns {
+		if err := n.Notify(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 输出到终端, 后台运行时输出会丢失, 应同时使用 file 等方式
+type Stdout struct {
+	W io.Writer
+}
+
+func (s *Stdout) Notify(m Message) error {
+	_, err := fmt.Fprintf(s.W, "\a\u001B[33m %s (%s) \u001b[0m\n", m.Title, m.Body)
+	return err
+}
@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeNotifier struct {
+	err  error
+	msgs []Message
+}
+
+func (f *fakeNotifier) Notify(m Message) error {
+	f.msgs = append(f.msgs, m)
+	return f.err
+}
+
+func TestNew(t *testing.T) {
+	full := Options{Webhook: "http://localhost/hook", File: "/tmp/remind.log", Out: &bytes.Buffer{}}
+	tests := []struct {
+		names   []string
+		opt     Options
+		want    string // 返回的类型, 多个时为 Multi(个数)
+		wantErr string
+	}{
+		{names: []string{"stdout"}, opt: full, want: "*notify.Stdout"},
+		{names: []string{" Desktop "}, opt: full, want: "notify.Desktop"},
+		{names: []string{"file"}, opt: full, want: "*notify.File"},
+		{names: []string{"webhook"}, opt: full, want: "*notify.Webhook"},
+		{names: []string{"stdout", "", "file", "webhook"}, opt: full, want: "Multi(3)"},
+		{names: nil, opt: full, want: "Multi(0)"},
+		{names: []string{"file"}, opt: Options{}, wantErr: "notify.file"},
+		{names: []string{"webhook"}, opt: Options{}, wantErr: "notify.webhook"},
+		{names: []string{"stdout", "sms"}, opt: full, wantErr: "不支持的通知方式: sms"},
+	}
+	for _, tt := range tests {
+		n, err := New(tt.names, tt.opt)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New(%q) err = %v, want %q", tt.names, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%q) err = %v", tt.names, err)
+			continue
+		}
+		got := fmt.Sprintf("%T", n)
+		if m, ok := n.(Multi); ok {
+			got = fmt.Sprintf("Multi(%d)", len(m))
+		}
+		if got != tt.want {
+			t.Errorf("New(%q) = %s, want %s", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestNewStdoutDefault(t *testing.T) {
+	n, err := New([]string{"stdout"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := n.(*Stdout); s.W != os.Stdout {
+		t.Errorf("stdout 默认输出 = %v, want os.Stdout", s.W)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	errA, errB := errors.New("a 失败"), errors.New("b 失败")
+	a, ok, b := &fakeNotifier{err: errA}, &fakeNotifier{}, &fakeNotifier{err: errB}
+	m := Message{Title: "提醒", Body: "开会"}
+
+	err := Multi{a, ok, b}.Notify(m)
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("err = %v, want both errors", err)
+	}
+	// 某一种失败不影响其它
+	for i, f := range []*fakeNotifier{a, ok, b} {
+		if len(f.msgs) != 1 || f.msgs[0] != m {
+			t.Errorf("notifier %d got %v", i, f.msgs)
+		}
+	}
+	if err := (Multi{ok}).Notify(m); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestStdout(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&Stdout{W: &buf}).Notify(Message{Title: "提醒", Body: "开会"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "\a") || !strings.Contains(buf.String(), "提醒 (开会)") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "remind.log")
+	f := &File{Path: path}
+	at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local)
+	for _, body := range []string{"开会", "喝水"} {
+		if err := f.Notify(Message{Title: "提醒", Body: body, Time: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-05-01 09:30:00 提醒 开会\n2024-05-01 09:30:00 提醒 喝水\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]string
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if got["body"] == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	w := &Webhook{URL: srv.URL, Client: srv.Client()}
+	if err := w.Notify(Message{Title: "提醒", Body: "开会", Time: at}); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	want := map[string]string{"title": "提醒", "body": "开会", "time": "2024-05-01T09:30:00Z"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	err := w.Notify(Message{Title: "提醒", Body: "fail", Time: at})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("err = %v, want status 500", err)
+	}
+}
+
+func TestWebhookUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	if err := (&Webhook{URL: url}).Notify(Message{}); err == nil {
+		t.Error("err = nil, want connection error")
+	}
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// POST JSON {"title": "", "body": "", "time": ""} 到 webhook 地址
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *Webhook) Notify(m Message) error {
+	data, err := json.Marshal(map[string]string{
+		"title": m.Title,
+		"body":  m.Body,
+		"time":  m.Time.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Post(w.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s 返回 %s", w.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package remind
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 提醒事项文件, 位于存储目录下, 每行一条, 可以写成 - [ ] 待办 的形式, 行中带有提醒时间(见 schedule.go),
+// 空行, # 开头的行和已完成的 - [x] 行会被忽略
+const File = "todolist"
+
+// 已提醒/稍后提醒的状态, 只对本机有效, 不提交到仓库
+const StateFile = ".note/remind.yaml"
+
+type Reminder struct {
+	Key  string // 由文本生成, 用于关联状态
+	Line int    // 在文件中的行号, 从 1 开始
+	Text string
+	Spec Spec
+	// 单独指定的通知方式, 行中写 notify:webhook,file, 为空时使用配置文件中的
+	Notify []string
+}
+
+var (
+	checkRegex  = regexp.MustCompile(`^\s*[-*]\s+\[([ xX])\]\s*`)
+	notifyRegex = regexp.MustCompile(`(?:^|\s)notify:([\w,]+)`)
+)
+
+// 解析提醒事项文件, 没有提醒时间的行跳过
+func Parse(content string) []Reminder {
+	var rs []Reminder
+	count := make(map[string]int)
+	for i, line := range strings.Split(content, "\n") {
+		text, done := TrimCheckbox(line)
+		if text == "" || strings.HasPrefix(text, "#") || done {
+			continue
+		}
+		var backends []string
+		if m := notifyRegex.FindStringSubmatch(text); m != nil {
+			backends = strings.Split(m[1], ",")
+			text = strings.TrimSpace(strings.Replace(text, strings.TrimSpace(m[0]), "", 1))
+		}
+		spec, err := ParseSpec(text)
+		if err != nil {
+			continue
+		}
+		// 相同文本的多行各自记录状态
+		count[text]++
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%d", text, count[text])))
+		rs = append(rs, Reminder{Key: hex.EncodeToString(sum[:6]), Line: i + 1, Text: text, Spec: spec, Notify: backends})
+	}
+	return rs
+}
+
+// 去掉行首的 - [ ] / - [x], 返回待办内容及是否已完成
+func TrimCheckbox(line string) (string, bool) {
+	m := checkRegex.FindStringSubmatch(line)
+	if m == nil {
+		return strings.TrimSpace(line), false
+	}
+	return strings.TrimSpace(line[len(m[0]):]), m[1] != " "
+}
+
+var durationRegex = regexp.MustCompile(`(?i)^` + durationPattern + `$`)
+
+// 解析 10min / 2h / 1d 这样的时长
+func ParseDuration(s string) (time.Duration, error) {
+	m := durationRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("无效的时长: %s, 举例 10min 2h 1d", s)
+	}
+	return parseDuration(m[1], m[2])
+}
+
+type state struct {
+	Seen    time.Time `yaml:"seen"`              // 第一次看到该提醒的时间
+	Fired   time.Time `yaml:"fired,omitempty"`   // 上一次提醒的时间
+	Snoozed time.Time `yaml:"snoozed,omitempty"` // 稍后提醒的时间
+}
+
+// 提醒调度, 提醒事项文件或状态文件被修改后 Reload 会重新读取,
+// 状态持久化后重启不会重复提醒, 停止期间错过的提醒在启动后补上一次
+type Scheduler struct {
+	root      string
+	reminders []Reminder
+	states    map[string]*state
+	fileTime  time.Time
+	stateTime time.Time
+	dirty     bool
+}
+
+func NewScheduler(root string) (*Scheduler, error) {
+	s := &Scheduler{root: root, states: make(map[string]*state)}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Scheduler) Reminders() []Reminder {
+	return s.reminders
+}
+
+func (s *Scheduler) Reload() error {
+	if err := s.loadState(); err != nil {
+		return err
+	}
+	path := filepath.Join(s.root, File)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.reminders = nil
+		s.fileTime = time.Time{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.fileTime) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s.reminders = Parse(string(data))
+	s.fileTime = info.ModTime()
+
+	// 新出现的提醒以文件修改时间作为起点, 停止期间写入的提醒启动后也能补上
+	seen := s.fileTime
+	if now := time.Now(); seen.After(now) {
+		seen = now
+	}
+	keys := make(map[string]bool)
+	for _, r := range s.reminders {
+		keys[r.Key] = true
+		if _, ok := s.states[r.Key]; !ok {
+			s.states[r.Key] = &state{Seen: seen}
+			s.dirty = true
+		}
+	}
+	for key := range s.states {
+		if !keys[key] {
+			delete(s.states, key)
+			s.dirty = true
+		}
+	}
+	return s.Save()
+}
+
+func (s *Scheduler) loadState() error {
+	path := filepath.Join(s.root, StateFile)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.stateTime) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	states := make(map[string]*state)
+	if err := yaml.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("%s: %w", StateFile, err)
+	}
+	s.states = states
+	s.stateTime = info.ModTime()
+	return nil
+}
+
+func (s *Scheduler) Save() error {
+	if !s.dirty {
+		return nil
+	}
+	data, err := yaml.Marshal(s.states)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.root, StateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		s.stateTime = info.ModTime()
+	}
+	s.dirty = false
+	return nil
+}
+
+// 下一次提醒时间, 稍后提醒优先, 没有下一次时返回零值
+func (s *Scheduler) Next(r Reminder) time.Time {
+	st := s.state(r)
+	if !st.Snoozed.IsZero() {
+		return st.Snoozed
+	}
+	from := st.Seen
+	if st.Fired.After(from) {
+		from = st.Fired
+	}
+	return r.Spec.Next(from, st.Seen)
+}
+
+// 到期的提醒, 连续错过多次的重复提醒也只返回一次
+func (s *Scheduler) Due(now time.Time) []Reminder {
+	var due []Reminder
+	for _, r := range s.reminders {
+		if next := s.Next(r); !next.IsZero() && !next.After(now) {
+			due = append(due, r)
+		}
+	}
+	return due
+}
+
+func (s *Scheduler) Fired(r Reminder, at time.Time) {
+	st := s.state(r)
+	st.Fired = at
+	st.Snoozed = time.Time{}
+	s.dirty = true
+}
+
+func (s *Scheduler) Snooze(r Reminder, until time.Time) {
+	s.state(r).Snoozed = until
+	s.dirty = true
+}
+
+func (s *Scheduler) state(r Reminder) *state {
+	st, ok := s.states[r.Key]
+	if !ok {
+		st = &state{Seen: time.Now()}
+		s.states[r.Key] = st
+		s.dirty = true
+	}
+	return st
+}
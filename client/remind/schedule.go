@@ -0,0 +1,230 @@
+package remind
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 提醒时间的写法, 出现在提醒事项文本中的任意位置:
+//
+//	2026-10-20 15:00 / 2026-10-20   指定时刻, 只写日期时默认 09:00
+//	15:00                           每天 15:00, 与旧版 todolist 的写法一致
+//	Mon 09:30 / 周一 09:30           下一个周一 09:30
+//	30min / 2h                      写入后 30 分钟 / 2 小时
+//	every day 08:00 / 每天 08:00     每天
+//	every Mon,Wed 09:30 / 每周一 09:30
+//	every workday 09:00 / 工作日 09:00
+//	every 30min / 每 2h              固定间隔
+
+type Kind int
+
+const (
+	Once     Kind = iota // 一次性, 由 at 给出
+	Clock                // 只写 HH:MM 时每天提醒, 限定星期时为一次性的下一个星期几 HH:MM
+	After                // 一次性, 写入后经过一段时间
+	Daily                // 重复, 每天或每周的若干天的 HH:MM
+	Interval             // 重复, 固定间隔
+)
+
+type Spec struct {
+	Kind     Kind
+	At       time.Time
+	Hour     int
+	Minute   int
+	Weekdays []time.Weekday // 为空表示每天
+	Duration time.Duration
+	Expr     string // 文本中匹配到的部分
+}
+
+const DefaultHour = 9
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday, "日": time.Sunday, "天": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday, "一": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday, "二": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday, "三": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday, "四": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday, "五": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday, "六": time.Saturday,
+}
+
+var workdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+const (
+	clockPattern    = `(\d{1,2}):(\d{2})`
+	durationPattern = `(\d+)\s*(min|m|h|d|分钟|小时|天)`
+	weekdayPattern  = `(?:\b(?:mon(?:day)?|tue(?:sday)?|wed(?:nesday)?|thu(?:rsday)?|fri(?:day)?|sat(?:urday)?|sun(?:day)?)\b|(?:周|星期)[一二三四五六日天])`
+)
+
+var (
+	everyRegex    = regexp.MustCompile(`(?i)(?:every\s+(day|workday|weekday|` + weekdayPattern + `(?:\s*,\s*` + weekdayPattern + `)*)|(每天|工作日|每` + weekdayPattern + `(?:\s*[,，、]\s*` + weekdayPattern + `)*))\s*` + clockPattern)
+	intervalRegex = regexp.MustCompile(`(?i)(?:every|每)\s*` + durationPattern)
+	dateRegex     = regexp.MustCompile(`(\d{4})-(\d{1,2})-(\d{1,2})(?:[ T]` + clockPattern + `)?`)
+	weekdayRegex  = regexp.MustCompile(`(?i)(` + weekdayPattern + `)\s*` + clockPattern)
+	clockRegex    = regexp.MustCompile(clockPattern)
+	afterRegex    = regexp.MustCompile(`(?i)(?:^|[^\w-])` + durationPattern + `(?:$|[^\w])`)
+	wordRegex     = regexp.MustCompile(`(?i)` + weekdayPattern)
+)
+
+// 从文本中找出提醒时间, 按重复 > 日期 > 星期 > 时刻 > 时长的顺序匹配
+func ParseSpec(s string) (Spec, error) {
+	if m := everyRegex.FindStringSubmatch(s); m != nil {
+		spec := Spec{Kind: Daily, Expr: m[0]}
+		days := m[1] + m[2]
+		switch strings.ToLower(days) {
+		case "day", "每天":
+		case "workday", "weekday", "工作日":
+			spec.Weekdays = workdays
+		default:
+			for _, w := range wordRegex.FindAllString(days, -1) {
+				d, ok := lookupWeekday(w)
+				if !ok {
+					return Spec{}, fmt.Errorf("无法识别的星期: %s", w)
+				}
+				spec.Weekdays = append(spec.Weekdays, d)
+			}
+		}
+		var err error
+		spec.Hour, spec.Minute, err = parseClock(m[3], m[4])
+		return spec, err
+	}
+	if m := intervalRegex.FindStringSubmatch(s); m != nil {
+		d, err := parseDuration(m[1], m[2])
+		if err != nil {
+			return Spec{}, err
+		}
+		return Spec{Kind: Interval, Duration: d, Expr: m[0]}, nil
+	}
+	if m := dateRegex.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		hour, minute := DefaultHour, 0
+		if m[4] != "" {
+			var err error
+			if hour, minute, err = parseClock(m[4], m[5]); err != nil {
+				return Spec{}, err
+			}
+		}
+		at := time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.Local)
+		if at.Month() != time.Month(month) || at.Day() != day {
+			return Spec{}, fmt.Errorf("无效的日期: %s", m[0])
+		}
+		return Spec{Kind: Once, At: at, Expr: m[0]}, nil
+	}
+	if m := weekdayRegex.FindStringSubmatch(s); m != nil {
+		d, ok := lookupWeekday(m[1])
+		if !ok {
+			return Spec{}, fmt.Errorf("无法识别的星期: %s", m[1])
+		}
+		hour, minute, err := parseClock(m[2], m[3])
+		return Spec{Kind: Clock, Hour: hour, Minute: minute, Weekdays: []time.Weekday{d}, Expr: m[0]}, err
+	}
+	if m := clockRegex.FindStringSubmatch(s); m != nil {
+		hour, minute, err := parseClock(m[1], m[2])
+		return Spec{Kind: Clock, Hour: hour, Minute: minute, Expr: m[0]}, err
+	}
+	if m := afterRegex.FindStringSubmatch(s); m != nil {
+		d, err := parseDuration(m[1], m[2])
+		if err != nil {
+			return Spec{}, err
+		}
+		return Spec{Kind: After, Duration: d, Expr: strings.TrimSpace(m[1] + m[2])}, nil
+	}
+	return Spec{}, fmt.Errorf("no valid time pattern found")
+}
+
+// 返回严格晚于 from 的下一次提醒时间, seen 为第一次看到该提醒的时间,
+// 一次性提醒只在 seen 之后触发一次, 没有下一次时返回零值
+func (s Spec) Next(from, seen time.Time) time.Time {
+	var t time.Time
+	switch s.Kind {
+	case Once:
+		t = s.At
+	case Clock:
+		if len(s.Weekdays) == 0 {
+			return s.nextClock(from)
+		}
+		t = s.nextClock(seen)
+	case After:
+		t = seen.Add(s.Duration)
+	case Daily:
+		return s.nextClock(from)
+	case Interval:
+		if s.Duration <= 0 {
+			return time.Time{}
+		}
+		if !from.Before(seen) {
+			n := from.Sub(seen)/s.Duration + 1
+			return seen.Add(n * s.Duration)
+		}
+		return seen.Add(s.Duration)
+	}
+	if t.After(from) {
+		return t
+	}
+	return time.Time{}
+}
+
+func (s Spec) Recurring() bool {
+	return s.Kind == Daily || s.Kind == Interval || s.Kind == Clock && len(s.Weekdays) == 0
+}
+
+// 严格晚于 from 的下一个 HH:MM, 限定星期时跳过其它日子
+func (s Spec) nextClock(from time.Time) time.Time {
+	day := time.Date(from.Year(), from.Month(), from.Day(), s.Hour, s.Minute, 0, 0, from.Location())
+	for i := 0; i < 8; i++ {
+		t := day.AddDate(0, 0, i)
+		if t.After(from) && s.matchWeekday(t.Weekday()) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s Spec) matchWeekday(d time.Weekday) bool {
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+	for _, w := range s.Weekdays {
+		if w == d {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(s, "每"), "星期"), "周")
+	d, ok := weekdayNames[s]
+	return d, ok
+}
+
+func parseClock(h, m string) (int, int, error) {
+	hour, _ := strconv.Atoi(h)
+	minute, _ := strconv.Atoi(m)
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid time: %02d:%02d", hour, minute)
+	}
+	return hour, minute, nil
+}
+
+func parseDuration(n, unit string) (time.Duration, error) {
+	v, err := strconv.Atoi(n)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("无效的时长: %s%s", n, unit)
+	}
+	d := time.Duration(v)
+	switch strings.ToLower(unit) {
+	case "min", "m", "分钟":
+		return d * time.Minute, nil
+	case "h", "小时":
+		return d * time.Hour, nil
+	default:
+		return d * 24 * time.Hour, nil
+	}
+}
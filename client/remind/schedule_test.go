@@ -0,0 +1,106 @@
+package remind
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseSpec(t *testing.T) {
+	mon, wed := []time.Weekday{time.Monday}, []time.Weekday{time.Monday, time.Wednesday}
+	tests := []struct {
+		s    string
+		want Spec
+	}{
+		{s: "开会 15:00", want: Spec{Kind: Clock, Hour: 15, Expr: "15:00"}},
+		{s: "Mon 09:30 周会", want: Spec{Kind: Clock, Hour: 9, Minute: 30, Weekdays: mon, Expr: "Mon 09:30"}},
+		{s: "周一 9:30", want: Spec{Kind: Clock, Hour: 9, Minute: 30, Weekdays: mon, Expr: "周一 9:30"}},
+		{s: "every day 08:00", want: Spec{Kind: Daily, Hour: 8, Expr: "every day 08:00"}},
+		{s: "每天 08:00 喝水", want: Spec{Kind: Daily, Hour: 8, Expr: "每天 08:00"}},
+		{s: "every workday 09:00", want: Spec{Kind: Daily, Hour: 9, Weekdays: workdays, Expr: "every workday 09:00"}},
+		{s: "工作日 09:00", want: Spec{Kind: Daily, Hour: 9, Weekdays: workdays, Expr: "工作日 09:00"}},
+		{s: "every Mon,Wed 09:30", want: Spec{Kind: Daily, Hour: 9, Minute: 30, Weekdays: wed, Expr: "every Mon,Wed 09:30"}},
+		{s: "每周一、周三 09:30", want: Spec{Kind: Daily, Hour: 9, Minute: 30, Weekdays: wed, Expr: "每周一、周三 09:30"}},
+		{s: "every 30min", want: Spec{Kind: Interval, Duration: 30 * time.Minute, Expr: "every 30min"}},
+		{s: "每 2h 起来走走", want: Spec{Kind: Interval, Duration: 2 * time.Hour, Expr: "每 2h"}},
+		{s: "2026-10-20 15:00", want: Spec{Kind: Once, At: date(20, 15, 0), Expr: "2026-10-20 15:00"}},
+		{s: "交报告 2026-10-20", want: Spec{Kind: Once, At: date(20, DefaultHour, 0), Expr: "2026-10-20"}},
+		{s: "30min 后喝水", want: Spec{Kind: After, Duration: 30 * time.Minute, Expr: "30min"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSpec(tt.s)
+		if err != nil {
+			t.Errorf("ParseSpec(%q) err = %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSpec(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpecError(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{s: "2026-02-30", want: "无效的日期"},
+		{s: "25:00", want: "invalid time"},
+		{s: "every day 08:60", want: "invalid time"},
+		{s: "没有时间", want: "no valid time pattern"},
+		{s: "version-2h", want: "no valid time pattern"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSpec(tt.s); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseSpec(%q) err = %v, want %q", tt.s, err, tt.want)
+		}
+	}
+}
+
+// 2026-10-16 是周五, 10-17 周六, 10-19 周一
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		from, seen time.Time
+		want       time.Time // 零值表示不再提醒
+		recurring  bool
+	}{
+		{name: "只写时刻当天", s: "15:00", from: date(17, 10, 0), seen: date(16, 8, 0), want: date(17, 15, 0), recurring: true},
+		{name: "只写时刻正好到点", s: "15:00", from: date(17, 15, 0), seen: date(16, 8, 0), want: date(18, 15, 0), recurring: true},
+		{name: "只写时刻每天重复", s: "15:00", from: date(17, 16, 0), seen: date(17, 16, 0), want: date(18, 15, 0), recurring: true},
+		{name: "星期几", s: "Mon 09:30", from: date(17, 10, 0), seen: date(17, 10, 0), want: date(19, 9, 30)},
+		{name: "星期几只提醒一次", s: "Mon 09:30", from: date(19, 9, 30), seen: date(17, 10, 0)},
+		{name: "星期几当天写入", s: "周一 09:30", from: date(19, 8, 0), seen: date(19, 8, 0), want: date(19, 9, 30)},
+		{name: "工作日跳过周末", s: "every workday 09:00", from: date(16, 10, 0), seen: date(1, 0, 0), want: date(19, 9, 0), recurring: true},
+		{name: "工作日当天", s: "工作日 09:00", from: date(19, 8, 0), seen: date(1, 0, 0), want: date(19, 9, 0), recurring: true},
+		{name: "每天", s: "每天 08:00", from: date(17, 8, 0), seen: date(1, 0, 0), want: date(18, 8, 0), recurring: true},
+		{name: "间隔从写入开始", s: "每 2h", from: date(17, 10, 0), seen: date(17, 10, 0), want: date(17, 12, 0), recurring: true},
+		{name: "间隔到点前", s: "每 2h", from: date(17, 11, 59), seen: date(17, 10, 0), want: date(17, 12, 0), recurring: true},
+		{name: "间隔正好到点", s: "every 2h", from: date(17, 12, 0), seen: date(17, 10, 0), want: date(17, 14, 0), recurring: true},
+		{name: "间隔早于写入", s: "every 30min", from: date(17, 9, 0), seen: date(17, 10, 0), want: date(17, 10, 30), recurring: true},
+		{name: "时长", s: "30min", from: date(17, 10, 0), seen: date(17, 10, 0), want: date(17, 10, 30)},
+		{name: "时长已过", s: "30min", from: date(17, 10, 30), seen: date(17, 10, 0)},
+		{name: "日期", s: "2026-10-20 15:00", from: date(17, 10, 0), seen: date(17, 10, 0), want: date(20, 15, 0)},
+		{name: "只写日期", s: "2026-10-20", from: date(17, 10, 0), seen: date(17, 10, 0), want: date(20, DefaultHour, 0)},
+		{name: "日期已过", s: "2026-10-20 15:00", from: date(20, 15, 0), seen: date(17, 10, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseSpec(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spec.Next(tt.from, tt.seen); !got.Equal(tt.want) {
+				t.Errorf("Next(%s, %s) = %s, want %s", tt.from, tt.seen, got, tt.want)
+			}
+			if got := spec.Recurring(); got != tt.recurring {
+				t.Errorf("Recurring() = %v, want %v", got, tt.recurring)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package trash
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 回收站, 位于存储目录的 .trash 下并随仓库提交, 每个被删除的文件或目录放在 .trash/<id>/ 下,
+// 原路径和删除时间记录在 .trash/items.yaml
+
+const (
+	Dir      = ".trash"
+	itemFile = Dir + "/items.yaml"
+	idLength = 4
+	idChars  = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+var ErrNotFound = errors.New("回收站中没有该项")
+
+type Item struct {
+	ID      string            `yaml:"-"`
+	Path    string            `yaml:"path"` // 删除前的相对路径
+	Deleted time.Time         `yaml:"deleted"`
+	IsDir   bool              `yaml:"dir,omitempty"`
+	NoteIDs map[string]string `yaml:"ids,omitempty"` // 删除前的笔记 ID, 相对路径 -> ID, 恢复时沿用
+}
+
+type Trash struct {
+	root  string
+	items map[string]*Item
+}
+
+func Open(root string) (*Trash, error) {
+	t := &Trash{root: root, items: make(map[string]*Item)}
+	data, err := os.ReadFile(filepath.Join(root, itemFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &t.items); err != nil {
+		return nil, fmt.Errorf("%s: %w", itemFile, err)
+	}
+	for id, item := range t.items {
+		item.ID = id
+	}
+	return t, nil
+}
+
+func (t *Trash) Save() error {
+	path := filepath.Join(t.root, itemFile)
+	if len(t.items) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(t.items)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// 按删除时间从新到旧
+func (t *Trash) Items() []*Item {
+	items := make([]*Item, 0, len(t.items))
+	for _, item := range t.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Deleted.Equal(items[j].Deleted) {
+			return items[i].Deleted.After(items[j].Deleted)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func (t *Trash) Get(id string) (*Item, error) {
+	item, ok := t.items[strings.ToLower(id)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return item, nil
+}
+
+// 把存储目录下的 rel 移到回收站
+func (t *Trash) Add(rel string, noteIDs map[string]string, now time.Time) (*Item, error) {
+	src := filepath.Join(t.root, rel)
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	item := &Item{ID: t.newID(), Path: filepath.ToSlash(rel), Deleted: now, IsDir: info.IsDir(), NoteIDs: noteIDs}
+	dst := t.path(item)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return nil, err
+	}
+	t.items[item.ID] = item
+	return item, nil
+}
+
+// 移回原路径, 原路径已被占用时返回错误
+func (t *Trash) Restore(id string) (*Item, error) {
+	item, err := t.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	dst := filepath.Join(t.root, item.Path)
+	if _, err := os.Stat(dst); err == nil {
+		return nil, fmt.Errorf("%s 已存在, 请先移走", item.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(t.path(item), dst); err != nil {
+		return nil, err
+	}
+	os.Remove(filepath.Join(t.root, Dir, item.ID))
+	delete(t.items, item.ID)
+	return item, nil
+}
+
+// 彻底删除早于 before 的项, 返回删除的项
+func (t *Trash) Empty(before time.Time) ([]*Item, error) {
+	var removed []*Item
+	for _, item := range t.Items() {
+		if !item.Deleted.Before(before) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(t.root, Dir, item.ID)); err != nil {
+			return removed, err
+		}
+		delete(t.items, item.ID)
+		removed = append(removed, item)
+	}
+	return removed, nil
+}
+
+// 回收站中保留原文件名, .trash/<id>/<name>
+func (t *Trash) path(item *Item) string {
+	return filepath.Join(t.root, Dir, item.ID, filepath.Base(item.Path))
+}
+
+func (t *Trash) newID() string {
+	for {
+		b := make([]byte, idLength)
+		for i := range b {
+			v, err := rand.Int(rand.Reader, big.NewInt(int64(len(idChars))))
+			if err != nil {
+				panic(err)
+			}
+			b[i] = idChars[v.Int64()]
+		}
+		id := string(b)
+		if _, exists := t.items[id]; exists {
+			continue
+		}
+		// 目录也不能已存在, 可能是别的机器删除后还没同步元数据
+		if _, err := os.Stat(filepath.Join(t.root, Dir, id)); err == nil {
+			continue
+		}
+		return id
+	}
+}
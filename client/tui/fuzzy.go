@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 模糊匹配: pattern 的字符按顺序出现在 s 中即为匹配, 不区分大小写, 空格分隔的多个词都要匹配.
+// 连续匹配, 匹配在单词开头或文件名中的得分更高
+
+type Match struct {
+	Index     int   // 在候选列表中的位置
+	Score     int   // 越大越好
+	Positions []int // 匹配到的字节位置, 用于高亮
+}
+
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 24
+	bonusBoundary    = 32 // 单词开头, 路径分隔符之后
+	bonusBasename    = 12 // 在文件名而不是目录中
+	penaltyGap       = 1
+)
+
+// 匹配一个候选, 不匹配时 ok 为 false
+func FuzzyMatch(pattern, s string) (int, []int, bool) {
+	total := 0
+	var positions []int
+	for _, word := range strings.Fields(pattern) {
+		score, pos, ok := matchWord(word, s)
+		if !ok {
+			return 0, nil, false
+		}
+		total += score
+		positions = append(positions, pos...)
+	}
+	sort.Ints(positions)
+	return total, positions, true
+}
+
+// 从每个可能的起点贪心匹配, 取得分最高的
+func matchWord(word, s string) (int, []int, bool) {
+	pattern := []rune(strings.ToLower(word))
+	lower := strings.ToLower(s)
+	if len(lower) != len(s) {
+		// 大小写转换改变了长度时退回逐字比较, 保证位置正确
+		lower = s
+	}
+	base := strings.LastIndex(s, "/") + 1
+	best, bestPos, found := 0, []int(nil), false
+	for start := 0; start < len(lower); {
+		first, n := utf8.DecodeRuneInString(lower[start:])
+		if first != pattern[0] {
+			start += n
+			continue
+		}
+		score, pos, ok := matchFrom(pattern, s, lower, start, base)
+		if !ok {
+			break
+		}
+		if !found || score > best {
+			best, bestPos, found = score, pos, true
+		}
+		start += n
+	}
+	return best, bestPos, found
+}
+
+func matchFrom(pattern []rune, s, lower string, start, base int) (int, []int, bool) {
+	score := 0
+	var pos []int
+	prev := -1
+	i := start
+	for _, p := range pattern {
+		for {
+			if i >= len(lower) {
+				return 0, nil, false
+			}
+			r, n := utf8.DecodeRuneInString(lower[i:])
+			if r == p {
+				score += scoreMatch
+				if prev >= 0 && prev == i-lastRuneLen(lower[:i]) {
+					score += bonusConsecutive
+				} else if prev >= 0 {
+					score -= penaltyGap * (i - prev)
+				}
+				if boundary(s, i) {
+					score += bonusBoundary
+				}
+				if i >= base {
+					score += bonusBasename
+				}
+				pos = append(pos, i)
+				prev = i
+				i += n
+				break
+			}
+			i += n
+		}
+	}
+	// 越短的候选越相关
+	score -= len(s) / 8
+	return score, pos, true
+}
+
+func lastRuneLen(s string) int {
+	_, n := utf8.DecodeLastRuneInString(s)
+	return n
+}
+
+func boundary(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	cur, _ := utf8.DecodeRuneInString(s[i:])
+	switch {
+	case prev == '/' || prev == '_' || prev == '-' || prev == '.' || prev == ' ':
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	}
+	return false
+}
+
+// 过滤并按得分排序, 得分相同时保持原顺序
+func Filter(pattern string, items []string) []Match {
+	var ms []Match
+	for i, item := range items {
+		if strings.TrimSpace(pattern) == "" {
+			ms = append(ms, Match{Index: i})
+			continue
+		}
+		if score, pos, ok := FuzzyMatch(pattern, item); ok {
+			ms = append(ms, Match{Index: i, Score: score, Positions: pos})
+		}
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].Score > ms[j].Score })
+	return ms
+}
+
+// 高亮匹配到的字符
+func Highlight(s string, positions []int, on, off string) string {
+	if len(positions) == 0 {
+		return s
+	}
+	var sb strings.Builder
+	next := 0
+	for i, r := range s {
+		if next < len(positions) && positions[next] == i {
+			sb.WriteString(on)
+			sb.WriteRune(r)
+			sb.WriteString(off)
+			for next < len(positions) && positions[next] == i {
+				next++
+			}
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
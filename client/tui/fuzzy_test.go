@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		ok         bool
+		positions  []int
+	}{
+		{pattern: "abc", s: "abc", ok: true, positions: []int{0, 1, 2}},
+		{pattern: "ABC", s: "xaxbxc", ok: true, positions: []int{1, 3, 5}},
+		{pattern: "acb", s: "abc", ok: false},
+		{pattern: "x", s: "abc", ok: false},
+		{pattern: "foo bar", s: "bar/foo.md", ok: true, positions: []int{0, 1, 2, 4, 5, 6}},
+		{pattern: "foo baz", s: "bar/foo.md", ok: false},
+		{pattern: "中文", s: "笔记/中文.md", ok: true, positions: []int{7, 10}},
+		{pattern: "nm", s: "NoteManager", ok: true, positions: []int{0, 4}},
+		{pattern: "", s: "abc", ok: true},
+	}
+	for _, tt := range tests {
+		_, pos, ok := FuzzyMatch(tt.pattern, tt.s)
+		if ok != tt.ok || ok && !reflect.DeepEqual(pos, tt.positions) {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, %v, want %v, %v", tt.pattern, tt.s, pos, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		better, worse string
+	}{
+		{pattern: "note", better: "note.md", worse: "nxoxtxe.md"},        // 连续匹配
+		{pattern: "note", better: "my_note.md", worse: "src/notes/x.go"}, // 文件名中
+		{pattern: "cfg", better: "cfg/a.md", worse: "docs/config.md"},    // 单词开头
+		{pattern: "todo", better: "todo", worse: "todo/archive/2024.md"}, // 更短
+	}
+	for _, tt := range tests {
+		a, _, okA := FuzzyMatch(tt.pattern, tt.better)
+		b, _, okB := FuzzyMatch(tt.pattern, tt.worse)
+		if !okA || !okB || a <= b {
+			t.Errorf("%q: %q = %d, %q = %d, want the first higher", tt.pattern, tt.better, a, tt.worse, b)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := []string{"src/notes/x.go", "readme.md", "my_note.md", "other/note.txt"}
+	var got []int
+	for _, m := range Filter("note", items) {
+		got = append(got, m.Index)
+	}
+	if want := []int{2, 3, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+	if ms := Filter(" ", items); len(ms) != len(items) {
+		t.Errorf("Filter(blank) = %d items, want all %d", len(ms), len(items))
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	if got := Highlight("笔记a", []int{3, 6}, "[", "]"); got != "笔[记][a]" {
+		t.Errorf("Highlight() = %q", got)
+	}
+}
@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"golang.org/x/term"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 全屏终端界面的基础: 在 /dev/tty 上进入原始模式和备用屏幕, 读取按键, 整屏重绘.
+// 不使用后台读取的 goroutine, 启动编辑器等外部程序时不会和它抢输入
+
+var ErrTimeout = errors.New("timeout")
+
+type Terminal struct {
+	tty   *os.File
+	state *term.State
+	out   *bufio.Writer
+	buf   []byte // 已读取还未解析的输入
+}
+
+func Open() (*Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.New("需要在终端中运行")
+	}
+	t := &Terminal{tty: tty, out: bufio.NewWriterSize(tty, 64*1024)}
+	if err := t.Resume(); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// 恢复终端并关闭
+func (t *Terminal) Close() {
+	t.Suspend()
+	t.tty.Close()
+}
+
+// 暂时退出全屏界面, 用于启动编辑器等外部程序
+func (t *Terminal) Suspend() {
+	if t.state == nil {
+		return
+	}
+	t.out.WriteString("\033[?25h\033[?1049l")
+	t.out.Flush()
+	term.Restore(int(t.tty.Fd()), t.state)
+	t.state = nil
+}
+
+func (t *Terminal) Resume() error {
+	if t.state != nil {
+		return nil
+	}
+	state, err := term.MakeRaw(int(t.tty.Fd()))
+	if err != nil {
+		return err
+	}
+	t.state = state
+	t.buf = nil
+	t.out.WriteString("\033[?1049h\033[?25l")
+	return t.out.Flush()
+}
+
+func (t *Terminal) Size() (int, int) {
+	w, h, err := term.GetSize(int(t.tty.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// 整屏输出, 每行超出宽度的部分截断
+func (t *Terminal) Draw(lines []string) error {
+	w, h := t.Size()
+	t.out.WriteString("\033[H")
+	for i := 0; i < h; i++ {
+		if i > 0 {
+			t.out.WriteString("\r\n")
+		}
+		if i < len(lines) {
+			t.out.WriteString(Truncate(lines[i], w))
+		}
+		t.out.WriteString("\033[0m\033[K")
+	}
+	return t.out.Flush()
+}
+
+// 读取一个按键, 超过 timeout 没有输入时返回 ErrTimeout, 调用方可以借机检查窗口大小变化
+func (t *Terminal) ReadKey(timeout time.Duration) (Key, error) {
+	if len(t.buf) == 0 {
+		if err := t.fill(timeout); err != nil {
+			return Key{}, err
+		}
+	}
+	// 单独的 ESC 后面可能还有转义序列, 稍等一下
+	if len(t.buf) == 1 && t.buf[0] == 0x1b {
+		t.fill(20 * time.Millisecond)
+	}
+	key, n := parseKey(t.buf)
+	t.buf = t.buf[n:]
+	return key, nil
+}
+
+func (t *Terminal) fill(timeout time.Duration) error {
+	if err := t.tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		// 不支持超时的终端只能阻塞读取
+		t.tty.SetReadDeadline(time.Time{})
+	}
+	b := make([]byte, 256)
+	n, err := t.tty.Read(b)
+	if n > 0 {
+		t.buf = append(t.buf, b[:n]...)
+		return nil
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrTimeout
+	}
+	if err == nil {
+		return ErrTimeout
+	}
+	return err
+}
+
+type KeyCode int
+
+const (
+	KeyRune KeyCode = iota
+	KeyCtrl         // Ctrl+字母, Rune 为小写字母
+	KeyEnter
+	KeyEsc
+	KeyBackspace
+	KeyTab
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPgUp
+	KeyPgDn
+	KeyHome
+	KeyEnd
+	KeyDelete
+	KeyUnknown
+)
+
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+func (k Key) Is(code KeyCode) bool {
+	return k.Code == code
+}
+
+func (k Key) IsRune(r rune) bool {
+	return k.Code == KeyRune && k.Rune == r
+}
+
+func (k Key) IsCtrl(r rune) bool {
+	return k.Code == KeyCtrl && k.Rune == r
+}
+
+var escapes = map[string]KeyCode{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[4~": KeyEnd, "[3~": KeyDelete,
+	"[5~": KeyPgUp, "[6~": KeyPgDn,
+}
+
+// 解析一个按键, 返回消耗的字节数
+func parseKey(b []byte) (Key, int) {
+	switch c := b[0]; {
+	case c == 0x1b:
+		if len(b) == 1 {
+			return Key{Code: KeyEsc}, 1
+		}
+		if b[1] != '[' && b[1] != 'O' {
+			return Key{Code: KeyEsc}, 1
+		}
+		// CSI 序列以 0x40-0x7e 之间的字节结束
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				seq := string(b[1 : i+1])
+				if code, ok := escapes[seq]; ok {
+					return Key{Code: code}, i + 1
+				}
+				return Key{Code: KeyUnknown}, i + 1
+			}
+		}
+		return Key{Code: KeyUnknown}, len(b)
+	case c == '\r' || c == '\n':
+		return Key{Code: KeyEnter}, 1
+	case c == '\t':
+		return Key{Code: KeyTab}, 1
+	case c == 0x7f || c == 0x08:
+		return Key{Code: KeyBackspace}, 1
+	case c < 0x20:
+		return Key{Code: KeyCtrl, Rune: rune('a' + c - 1)}, 1
+	}
+	r, n := utf8.DecodeRune(b)
+	if r == utf8.RuneError && n <= 1 && !utf8.FullRune(b) {
+		// 不完整的多字节字符, 丢弃
+		return Key{Code: KeyUnknown}, len(b)
+	}
+	return Key{Code: KeyRune, Rune: r}, n
+}
+
+// 单行输入框的编辑, 返回新内容
+func EditLine(s string, k Key) string {
+	switch {
+	case k.Is(KeyBackspace):
+		if s != "" {
+			_, n := utf8.DecodeLastRuneInString(s)
+			s = s[:len(s)-n]
+		}
+	case k.IsCtrl('u'):
+		s = ""
+	case k.IsCtrl('w'):
+		s = strings.TrimRight(s, " ")
+		if i := strings.LastIndexAny(s, " /"); i >= 0 {
+			s = s[:i+1]
+		} else {
+			s = ""
+		}
+	case k.Is(KeyRune):
+		s += string(k.Rune)
+	}
+	return s
+}
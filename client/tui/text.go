@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 带 ANSI 颜色的文本的显示宽度计算与截断, 中日韩文字和全角符号占两列
+
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0, unicode.Is(unicode.Mn, r):
+		return 0
+	case r < 0x1100:
+		return 1
+	case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hangul, r),
+		unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r),
+		r >= 0x3000 && r <= 0x303f,                             // 中文标点
+		r >= 0xff00 && r <= 0xff60, r >= 0xffe0 && r <= 0xffe6, // 全角字符
+		r >= 0x1f300 && r <= 0x1faff: // emoji
+		return 2
+	}
+	return 1
+}
+
+// 显示宽度, 忽略 ANSI 转义序列
+func Width(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		w += RuneWidth(r)
+		i += n
+	}
+	return w
+}
+
+// 截断到 w 列, 保留颜色, 制表符按 4 个空格处理
+func Truncate(s string, w int) string {
+	if !strings.ContainsAny(s, "\t") && len(s) <= w {
+		return s
+	}
+	var sb strings.Builder
+	col := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			sb.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		i += n
+		if r == '\t' {
+			r, n = ' ', 4-col%4
+		} else {
+			n = 1
+		}
+		for ; n > 0; n-- {
+			rw := RuneWidth(r)
+			if col+rw > w {
+				return sb.String()
+			}
+			sb.WriteRune(r)
+			col += rw
+		}
+	}
+	return sb.String()
+}
+
+// 截断并用空格补齐到 w 列
+func Pad(s string, w int) string {
+	s = Truncate(s, w)
+	if n := w - Width(s); n > 0 {
+		s += strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// 按行拆分带颜色的文本, 每行开头补上上一行未结束的颜色, 截断时不会丢失颜色
+func SplitLines(s string) []string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	active := ""
+	for i, line := range lines {
+		lines[i] = active + line
+		for j := 0; j < len(line); {
+			n := escapeLen(line[j:])
+			if n == 0 {
+				j++
+				continue
+			}
+			seq := line[j : j+n]
+			if seq == "\033[0m" || seq == "\033[m" {
+				active = ""
+			} else if strings.HasSuffix(seq, "m") {
+				active += seq
+			}
+			j += n
+		}
+	}
+	return lines
+}
+
+// 去掉 ANSI 转义序列
+func Strip(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return sb.String()
+}
+
+// s 开头的 CSI 转义序列长度, 不是转义序列时为 0
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -52,6 +52,7 @@ const (
 	Italic    = "\u001B[3m" // 斜体（部分终端不支持）
 	Blink     = "\u001B[5m" // 闪烁（部分终端禁用）
 	Reverse   = "\u001B[7m" // 反转前景/背景色
+	Strike    = "\u001B[9m" // 删除线
 )
 
 func Test() {
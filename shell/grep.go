@@ -2,7 +2,6 @@ package shell
 
 import (
 	"bufio"
-	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -18,19 +17,21 @@ const (
 	resetColor = "\033[0m"
 )
 
-var (
-	dirPath    = flag.String("d", ".", "Search directory")
-	keyword    = flag.String("k", "", "Keywords (| for OR, & for AND with order)")
-	workers    = flag.Int("w", 10, "Worker goroutines")
-	fileMatch  = flag.String("f", ".*", "Filename pattern")
-	contextLen = flag.Int("l", 40, "内容长度")
-)
+// 搜索参数, 由命令行 note grep 的 -d -k -w -f -l 设置
+type SearchOptions struct {
+	Dir        string // Search directory
+	Keyword    string // Keywords (| for OR, & for AND with order)
+	Workers    int    // Worker goroutines
+	FileMatch  string // Filename pattern
+	ContextLen int    // 内容长度
+}
 
 type searchPattern struct {
 	regex      *regexp.Regexp
 	keywords   []string
 	isAndMode  bool
 	colorRegex *regexp.Regexp
+	contextLen int
 }
 
 type task struct {
@@ -38,22 +39,21 @@ type task struct {
 	info os.FileInfo
 }
 
-func Search() {
-	//os.Args = os.Args[1:]
-	flag.Parse()
+func Search(opt SearchOptions) {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
-	if *keyword == "" {
+	if opt.Keyword == "" {
 		fmt.Println("必须指定关键字 (-k)")
 		os.Exit(1)
 	}
 
 	// 解析关键词模式
-	pattern, err := parseSearchPattern(*keyword)
+	pattern, err := parseSearchPattern(opt.Keyword)
 	if err != nil {
 		fmt.Printf("正则表达式错误: %v\n", err)
 		os.Exit(1)
 	}
+	pattern.contextLen = opt.ContextLen
 
 	// 创建任务和结果通道
 	taskChan := make(chan task, 100)
@@ -61,7 +61,7 @@ func Search() {
 
 	// 启动工作池
 	var wg sync.WaitGroup
-	for i := 0; i < *workers; i++ {
+	for i := 0; i < opt.Workers; i++ {
 		wg.Add(1)
 		//fmt.Println("start:", i)
 		go worker(taskChan, resultChan, pattern, &wg, i)
@@ -74,7 +74,7 @@ func Search() {
 	}()
 
 	// 生成任务
-	go generateTasks(taskChan)
+	go generateTasks(taskChan, opt)
 
 	// 输出结果
 	for res := range resultChan {
@@ -211,8 +211,8 @@ func processFile(path string, pattern *searchPattern, results chan<- string) {
 			start := match[0]
 			end := match[1]
 
-			ctxStart := max(0, start-*contextLen)
-			ctxEnd := min(len(coloredLine), end+*contextLen)
+			ctxStart := max(0, start-pattern.contextLen)
+			ctxEnd := min(len(coloredLine), end+pattern.contextLen)
 			context := coloredLine[ctxStart:ctxEnd]
 
 			// 去除可能的颜色代码截断
@@ -244,9 +244,9 @@ func fixColorCodes(s string) string {
 	return s
 }
 
-func generateTasks(taskChan chan<- task) {
-	fileRegex := regexp.MustCompile(*fileMatch)
-	filepath.Walk(*dirPath, func(path string, info os.FileInfo, err error) error {
+func generateTasks(taskChan chan<- task, opt SearchOptions) {
+	fileRegex := regexp.MustCompile(opt.FileMatch)
+	filepath.Walk(opt.Dir, func(path string, info os.FileInfo, err error) error {
 		if !info.IsDir() && fileRegex.MatchString(info.Name()) {
 			taskChan <- task{path, info}
 		}
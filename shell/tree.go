@@ -1,7 +1,9 @@
 package shell
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -12,16 +14,90 @@ import (
 
 func Init(root string) {
 	//root := "./db" // 指定根目录
-	printDir(root)
+	t := &treePrinter{w: os.Stdout, color: true}
+	t.printDir(root)
 	entries := getSortedEntries(root)
-	printTree(root, entries, "", make([]int, 0))
+	t.printTree(root, entries, "", make([]int, 0))
 }
 
-func printDir(dir string) {
-	fmt.Printf("%s%s%s%s\n", BrightCyan, Underline, dir, ResetAll)
+type TreeOptions struct {
+	// 只打印 Keep 返回 true 的文件以及包含这些文件的目录, 下标与完整目录树保持一致, 为空时打印全部
+	Keep func(path string) bool
+	// 追加在文件名后面的说明, 比如笔记 ID
+	Label func(path string) string
 }
-func printFile(file string) {
-	fmt.Printf("%s%s%s\n", Yellow, file, ResetAll)
+
+func InitWith(root string, opt TreeOptions) {
+	t := &treePrinter{w: os.Stdout, color: true, opt: opt}
+	t.printDir(root)
+	entries := getSortedEntries(root)
+	t.printTree(root, entries, "", make([]int, 0))
+}
+
+// 获取不带颜色的目录树文本, 供 mcp 等非终端场景使用
+
+func TreeString(root string, opt TreeOptions) string {
+	var buf bytes.Buffer
+	t := &treePrinter{w: &buf, opt: opt}
+	t.printDir(root)
+	entries := getSortedEntries(root)
+	t.printTree(root, entries, "", make([]int, 0))
+	return buf.String()
+}
+
+type treePrinter struct {
+	w     io.Writer
+	color bool
+	opt   TreeOptions
+}
+
+// 过滤后需要打印的条目下标
+func (t *treePrinter) visible(parentPath string, entries []fs.DirEntry) []int {
+	shown := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		fullPath := filepath.Join(parentPath, entry.Name())
+		if t.opt.Keep == nil || t.hasVisible(fullPath, entry) {
+			shown = append(shown, i)
+		}
+	}
+	return shown
+}
+
+func (t *treePrinter) hasVisible(path string, entry fs.DirEntry) bool {
+	if !entry.IsDir() {
+		return t.opt.Keep(path)
+	}
+	for _, sub := range getSortedEntries(path) {
+		if t.hasVisible(filepath.Join(path, sub.Name()), sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *treePrinter) printDir(dir string) {
+	if t.color {
+		fmt.Fprintf(t.w, "%s%s%s%s\n", BrightCyan, Underline, dir, ResetAll)
+	} else {
+		fmt.Fprintf(t.w, "%s/\n", dir)
+	}
+}
+func (t *treePrinter) printFile(path string) {
+	file := filepath.Base(path)
+	var label string
+	if t.opt.Label != nil {
+		label = t.opt.Label(path)
+	}
+	switch {
+	case label == "" && t.color:
+		fmt.Fprintf(t.w, "%s%s%s\n", Yellow, file, ResetAll)
+	case label == "":
+		fmt.Fprintf(t.w, "%s\n", file)
+	case t.color:
+		fmt.Fprintf(t.w, "%s%s%s %s%s%s\n", Yellow, file, ResetAll, BrightBlack, label, ResetAll)
+	default:
+		fmt.Fprintf(t.w, "%s %s\n", file, label)
+	}
 }
 
 func ColorPrint(color, text string) {
@@ -29,25 +105,27 @@ func ColorPrint(color, text string) {
 }
 
 // 递归打印目录结构
-func printTree(parentPath string, entries []fs.DirEntry, prefix string, fatherIndex []int) {
-	for i, entry := range entries {
-		isLast := i == len(entries)-1
+func (t *treePrinter) printTree(parentPath string, entries []fs.DirEntry, prefix string, fatherIndex []int) {
+	shown := t.visible(parentPath, entries)
+	for n, i := range shown {
+		entry := entries[i]
+		isLast := n == len(shown)-1
 		connector := "├── "
 		if isLast {
 			connector = "└── "
 		}
 		index := i + 1
 		if len(fatherIndex) > 0 {
-			fmt.Printf("%s%s%s.%d ", prefix, connector, formatIndex(fatherIndex...), index)
+			fmt.Fprintf(t.w, "%s%s%s.%d ", prefix, connector, formatIndex(fatherIndex...), index)
 		} else {
-			fmt.Printf("%s%s%d ", prefix, connector, index)
+			fmt.Fprintf(t.w, "%s%s%d ", prefix, connector, index)
 		}
 		// 打印当前条目名称
 		if entry.IsDir() {
-			printDir(entry.Name())
+			t.printDir(entry.Name())
 			//fmt.Printf("%s%s\u001B[32m%s\u001B[0m\n", prefix, connector, entry.Name())
 		} else {
-			printFile(entry.Name())
+			t.printFile(filepath.Join(parentPath, entry.Name()))
 		}
 
 		// 如果是目录，递归打印子项
@@ -61,7 +139,7 @@ func printTree(parentPath string, entries []fs.DirEntry, prefix string, fatherIn
 				} else {
 					newPrefix += "│   "
 				}
-				printTree(fullPath, subEntries, newPrefix, append(fatherIndex, index))
+				t.printTree(fullPath, subEntries, newPrefix, append(fatherIndex, index))
 			}
 		}
 	}
@@ -74,10 +152,10 @@ func getSortedEntries(path string) []fs.DirEntry {
 		return nil
 	}
 
-	// 过滤掉 .git 目录
+	// 过滤掉 .git, .note(索引等内部数据) 以及 .gitignore 等隐藏文件
 	filteredEntries := make([]os.DirEntry, 0)
 	for _, entry := range entries {
-		if entry.Name() != ".git" {
+		if !strings.HasPrefix(entry.Name(), ".") {
 			filteredEntries = append(filteredEntries, entry)
 		}
 	}
@@ -90,23 +168,27 @@ func getSortedEntries(path string) []fs.DirEntry {
 	return filteredEntries
 }
 
-var mapStr = make(map[string]string)
+// 目录下按名称排序的条目, 不含隐藏文件, 顺序与目录树下标一致
+func SortedEntries(path string) []fs.DirEntry {
+	return getSortedEntries(path)
+}
 
 // 获取map[下标] 路径文件
+// 每次调用返回新的 map, mcp 等常驻进程中目录变化后不会残留旧下标
 
 func GetKeyMap(root string) map[string]string {
 	entries := getSortedEntries(root)
-	return getKvMap(root, entries, "", make([]int, 0), false)
+	return getKvMap(make(map[string]string), root, entries, "", make([]int, 0), false)
 }
 
 // 获取map[路径文件] 下标
 
 func GetValMap(root string) map[string]string {
 	entries := getSortedEntries(root)
-	return getKvMap(root, entries, "", make([]int, 0), true)
+	return getKvMap(make(map[string]string), root, entries, "", make([]int, 0), true)
 }
 
-func getKvMap(parentPath string, entries []fs.DirEntry, prefix string, fatherIndex []int, isRevert bool) map[string]string {
+func getKvMap(mapStr map[string]string, parentPath string, entries []fs.DirEntry, prefix string, fatherIndex []int, isRevert bool) map[string]string {
 	for i, entry := range entries {
 		index := i + 1
 		var keyString string
@@ -131,7 +213,7 @@ func getKvMap(parentPath string, entries []fs.DirEntry, prefix string, fatherInd
 			subEntries := getSortedEntries(fullPath)
 			if len(subEntries) > 0 {
 				newPrefix := prefix
-				getKvMap(fullPath, subEntries, newPrefix, append(fatherIndex, index), isRevert)
+				getKvMap(mapStr, fullPath, subEntries, newPrefix, append(fatherIndex, index), isRevert)
 			}
 		}
 	}